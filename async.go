@@ -0,0 +1,291 @@
+package otris
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy defines what the AsyncWriter does with a record when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the caller until the buffer has space, no record is lost.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the record being written.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered record to make space for the record being written.
+	OverflowDropOldest
+)
+
+// String returns the name of the policy.
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultAsyncSize is the default number of records buffered by the AsyncWriter.
+const DefaultAsyncSize = 1024
+
+// DefaultDropReportInterval is the interval of the warning records about the dropped records.
+const DefaultDropReportInterval = 10 * time.Second
+
+// AsyncWriter is an io.Writer that buffers the written records in a bounded ring buffer
+// and writes them to the underlying writer in batches from a background goroutine,
+// so a slow writer doesn't stall the logging goroutines.
+// Every Write must be a whole record, as the Handler does.
+//
+// After Close, the records are written synchronously, so the shutdown logs are not lost
+// if the underlying writer is still open, e.g. os.Stdout.
+type AsyncWriter struct {
+	w      io.Writer
+	policy OverflowPolicy
+
+	wmu     sync.Mutex // serializes the writes to w, it's locked before mu
+	mu      sync.Mutex
+	cond    *sync.Cond // signaled when records are taken from the ring and written
+	ring    [][]byte
+	head    int
+	n       int
+	seq     uint64 // number of the buffered records, the position after the last one
+	written uint64 // position after the last written record, the dropped records count as written
+	err     error  // the last error of w, it's returned by Flush
+	batch   []byte
+
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+
+	dropped  atomic.Uint64
+	reported uint64 // dropped records already reported, used only by the flusher
+	report   func(dropped uint64)
+	interval time.Duration
+}
+
+// NewAsyncWriter creates a new AsyncWriter buffering up to size records, DefaultAsyncSize if size is not positive,
+// and starts its flusher goroutine. The AsyncWriter must be closed to stop the goroutine.
+func NewAsyncWriter(w io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	return newAsyncWriter(w, size, policy, nil, DefaultDropReportInterval)
+}
+
+func newAsyncWriter(w io.Writer, size int, policy OverflowPolicy, report func(dropped uint64), interval time.Duration) *AsyncWriter {
+	if size <= 0 {
+		size = DefaultAsyncSize
+	}
+	aw := &AsyncWriter{
+		w:        w,
+		policy:   policy,
+		ring:     make([][]byte, size),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		report:   report,
+		interval: interval,
+	}
+	aw.cond = sync.NewCond(&aw.mu)
+	go aw.run()
+	return aw
+}
+
+// Write buffers a copy of p, it returns an error only after Close, from the underlying writer.
+// If the buffer is full, the record is handled by the OverflowPolicy.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	for !w.closed && w.n == len(w.ring) {
+		switch w.policy {
+		case OverflowDropNewest:
+			w.mu.Unlock()
+			w.dropped.Add(1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.head = (w.head + 1) % len(w.ring)
+			w.n--
+			w.dropped.Add(1)
+		default:
+			w.cond.Wait()
+		}
+	}
+	if w.closed {
+		w.mu.Unlock()
+		return w.writeSync(p)
+	}
+	i := (w.head + w.n) % len(w.ring)
+	w.ring[i] = append(w.ring[i][:0], p...)
+	w.n++
+	w.seq++
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// writeSync writes the buffered records and then p to the underlying writer.
+func (w *AsyncWriter) writeSync(p []byte) (int, error) {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	w.drain()
+	return w.w.Write(p)
+}
+
+// Flush waits until all records buffered before the call are written to the underlying writer
+// and flushes it. The records buffered during the call are not waited for, so Flush returns
+// even if other goroutines keep logging. It returns the last error of the underlying writer since the previous Flush.
+func (w *AsyncWriter) Flush() error {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	w.mu.Lock()
+	for seq := w.seq; w.written < seq; {
+		w.cond.Wait()
+	}
+	err := w.err
+	w.err = nil
+	w.mu.Unlock()
+
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	return errors.Join(err, flushWriter(w.w))
+}
+
+// Sync flushes the AsyncWriter and commits the underlying writer to the stable storage.
+func (w *AsyncWriter) Sync() error {
+	err := w.Flush()
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	return errors.Join(err, syncWriter(w.w))
+}
+
+// Close writes the buffered records and the report of the dropped records, stops the flusher goroutine
+// and closes the underlying writer, except os.Stdout and os.Stderr.
+func (w *AsyncWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+		close(w.stop)
+		<-w.done
+
+		err := w.Flush()
+		w.wmu.Lock()
+		defer w.wmu.Unlock()
+		w.closeErr = errors.Join(err, closeWriter(w.w))
+	})
+	return w.closeErr
+}
+
+// Dropped returns the number of the dropped records.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// run is the flusher goroutine.
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	var tick <-chan time.Time
+	if w.report != nil && w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-w.wake:
+			w.flush()
+		case <-tick:
+			w.reportDropped()
+		case <-w.stop:
+			w.flush()
+			w.reportDropped()
+			return
+		}
+	}
+}
+
+// flush writes the buffered records in batches, wmu is unlocked between them,
+// so Flush isn't blocked while the records keep coming.
+func (w *AsyncWriter) flush() {
+	for {
+		w.wmu.Lock()
+		ok := w.writeBatch()
+		w.wmu.Unlock()
+		if !ok {
+			return
+		}
+	}
+}
+
+// drain writes the buffered records in batches, wmu must be locked.
+func (w *AsyncWriter) drain() {
+	for w.writeBatch() {
+	}
+}
+
+// writeBatch writes the buffered records as one batch, wmu must be locked.
+// It returns false if there are no buffered records.
+func (w *AsyncWriter) writeBatch() bool {
+	w.mu.Lock()
+	if w.n == 0 {
+		w.mu.Unlock()
+		return false
+	}
+	w.batch = w.batch[:0]
+	for ; w.n > 0; w.n-- {
+		w.batch = append(w.batch, w.ring[w.head]...)
+		w.head = (w.head + 1) % len(w.ring)
+	}
+	// The batch ends with the last buffered record, the dropped records before it are passed too.
+	end := w.seq
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	_, err := w.w.Write(w.batch)
+	w.mu.Lock()
+	if err != nil {
+		w.err = err
+	}
+	w.written = end
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	return true
+}
+
+// reportDropped reports the records dropped since the previous report.
+func (w *AsyncWriter) reportDropped() {
+	if w.report == nil {
+		return
+	}
+	if total := w.dropped.Load(); total > w.reported {
+		w.report(total - w.reported)
+		w.reported = total
+	}
+}
+
+// DroppedKey is the key of the number of the dropped records in the warning records of the async mode.
+const DroppedKey = "dropped"
+
+// reportDropped logs the warning record about the records dropped by the AsyncWriter.
+func (h *Handler) reportDropped(dropped uint64) {
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "otris: log records dropped", 0)
+	r.AddAttrs(slog.Uint64(DroppedKey, dropped))
+	_ = h.handle(context.Background(), r, nil)
+}
@@ -0,0 +1,186 @@
+package otris
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks the first write until it's released.
+type gatedWriter struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	started  chan struct{}
+	released chan struct{}
+	once     sync.Once
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{started: make(chan struct{}), released: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.released
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	// Test cases
+	cases := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{policy: OverflowDropNewest, want: "123", dropped: 2},
+		{policy: OverflowDropOldest, want: "145", dropped: 2},
+	}
+
+	for _, test := range cases {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			gw := newGatedWriter()
+			var reported uint64
+			w := newAsyncWriter(gw, 2, test.policy, func(dropped uint64) { reported += dropped }, 0)
+
+			w.Write([]byte("1"))
+			<-gw.started
+			for i := 2; i <= 5; i++ {
+				w.Write([]byte(strconv.Itoa(i)))
+			}
+			close(gw.released)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("6"))
+
+			if got, want := gw.String(), test.want+"6"; got != want {
+				t.Errorf("\ngot  %s\nwant %s", got, want)
+			}
+			if w.Dropped() != test.dropped || reported != test.dropped {
+				t.Errorf("dropped %d, reported %d, want %d", w.Dropped(), reported, test.dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	var got bytes.Buffer
+	w := NewAsyncWriter(&got, 2, OverflowBlock)
+
+	var want bytes.Buffer
+	for i := 0; i < 100; i++ {
+		line := strconv.Itoa(i) + "\n"
+		w.Write([]byte(line))
+		want.WriteString(line)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slowWriter sleeps on every write.
+type slowWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterFlushWhileWriting(t *testing.T) {
+	sw := new(slowWriter)
+	w := NewAsyncWriter(sw, 4, OverflowBlock)
+
+	// The producers are faster than the writer, so the buffer is never empty.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(stop)
+		wg.Wait()
+		w.Close()
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					w.Write([]byte("x\n"))
+				}
+			}
+		}()
+	}
+
+	w.Write([]byte("flushed\n"))
+	done := make(chan error, 1)
+	go func() { done <- w.Flush() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush is blocked by the concurrent writes")
+	}
+	if !strings.Contains(sw.String(), "flushed\n") {
+		t.Errorf("\ngot  %s\nwant %s", "no flushed record", "flushed")
+	}
+}
+
+func TestHandlerBuilderWithAsync(t *testing.T) {
+	gw := newGatedWriter()
+	h := NewHandlerBuilder().WithWriter(gw).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).
+		WithAsync(1, OverflowDropNewest).Build()
+	logger := slog.New(h).WithGroup("g")
+
+	logger.Info("first")
+	<-gw.started
+	logger.Info("second")
+	logger.Info("third")
+	close(gw.released)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after close")
+
+	want := "level=INFO msg=first\n" +
+		"level=INFO msg=second\n" +
+		"level=WARN msg=\"otris: log records dropped\" dropped=1\n" +
+		"level=INFO msg=\"after close\"\n"
+	if gw.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", gw.String(), want)
+	}
+}
@@ -0,0 +1,87 @@
+package otris
+
+import (
+	"github.com/mattn/go-isatty"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode defines when the Handler emits the colors.
+type ColorMode int
+
+const (
+	// ColorAuto enables the colors if the writer is a terminal, it honors NO_COLOR, FORCE_COLOR and TERM=dumb.
+	// It's resolved when the Handler is created.
+	ColorAuto ColorMode = iota
+	// ColorAlways emits the colors even if the writer is not a terminal.
+	ColorAlways
+	// ColorNever never emits the colors.
+	ColorNever
+)
+
+// IsTerminal reports whether the writer is a terminal, it must have the `Fd() uintptr` method like *os.File.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// resolveColorMode returns ColorAlways or ColorNever for ColorAuto, detected from the writer.
+func resolveColorMode(mode ColorMode, w io.Writer) ColorMode {
+	if mode != ColorAuto {
+		return mode
+	}
+	if detectColor(IsTerminal(w)) {
+		return ColorAlways
+	}
+	return ColorNever
+}
+
+// detectColor reports whether the colors should be emitted to the writer.
+// NO_COLOR disables the colors, FORCE_COLOR enables them, or disables them if it's "0" or "false",
+// and TERM=dumb disables them on the terminals.
+func detectColor(tty bool) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(force) {
+		case "0", "false", "no", "off":
+			return false
+		default:
+			return true
+		}
+	}
+	return tty && os.Getenv("TERM") != "dumb"
+}
+
+// autoFormat is the format selected by WithAutoFormat, it's applied in Build when the writer is known.
+type autoFormat struct {
+	fallback Format
+	// The options before WithAutoFormat, they are kept by the fallback format.
+	color  LevelColorMap
+	layout string
+	sep    string
+}
+
+// apply selects the format by the writer of the builder.
+// The color map, the layout and the separator set after WithAutoFormat override the defaults of the format.
+func (a *autoFormat) apply(b *HandlerBuilder) {
+	color, layout, sep := b.h.color, b.h.layout, b.h.sep
+	tty := IsTerminal(b.h.w)
+	if tty || detectColor(tty) {
+		b.WithPretty()
+	} else {
+		b.h.color, b.h.layout, b.h.sep = a.color, a.layout, a.sep
+		switch a.fallback {
+		case FormatJSON:
+			b.WithJSON()
+		case FormatLogfmt:
+			b.WithLogfmt()
+		}
+	}
+	b.WithColor(color).WithTimeLayout(layout).WithSeparator(sep)
+}
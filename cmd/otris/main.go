@@ -0,0 +1,239 @@
+// Command otris prettifies JSON and logfmt logs.
+//
+// It reads the lines from the files or from stdin, re-renders the log lines
+// through the pretty otris handler and passes the other lines through unchanged.
+//
+// Usage:
+//
+//	kubectl logs deploy/app | otris -level info
+//	otris -follow -keys app.log
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Totus-Floreo/otris"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	var (
+		colors    = flag.String("color", "auto", "when to color the output: auto, always or never")
+		colorMap  = flag.String("colormap", "default", "level color map: default, empty or the colors overriding the default ones, e.g. info=green,error=hired+bold")
+		values    = flag.Bool("values", true, "color the attribute values, e.g. HTTP and SQL codes")
+		layout    = flag.String("layout", otris.DefaultPrettyDateTimeLayout, "time layout of the output")
+		sep       = flag.String("sep", otris.PrettySep, "separator of the output")
+		level     = flag.String("level", otris.GetLevelName(otris.LevelFx), "minimum level of the rendered lines")
+		keys      = flag.Bool("keys", false, "render the attribute keys")
+		columns   = flag.Bool("columns", false, "align the level and the message into columns")
+		multiline = flag.Bool("multiline", false, "render the groups and the multi-line values beneath the line")
+		follow    = flag.Bool("follow", false, "keep reading the files as they grow")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var colorMode otris.ColorMode
+	switch *colors {
+	case "always":
+		colorMode = otris.ColorAlways
+	case "never":
+		colorMode = otris.ColorNever
+	case "auto":
+		colorMode = otris.ColorAuto
+	default:
+		fail(fmt.Errorf("unknown color mode %q", *colors))
+	}
+
+	minLevel, err := otris.ParseLevel(*level)
+	if err != nil {
+		fail(err)
+	}
+
+	out := &lockedWriter{w: os.Stdout}
+	b := otris.NewHandlerBuilder().
+		WithPretty().
+		WithWriter(out).
+		WithTimeLayout(*layout).
+		WithSeparator(*sep).
+		WithColorMode(colorMode).
+		WithOptions(&slog.HandlerOptions{Level: minLevel})
+	levelColors, err := parseColorMap(*colorMap)
+	if err != nil {
+		fail(err)
+	}
+	b.WithColor(levelColors)
+	if *values {
+		b.WithValueColor(otris.DefaultColorMapV2)
+	}
+	if *keys {
+		b.WithPrettyKeys()
+	}
+	if *columns {
+		b.WithColumns()
+	}
+	if *multiline {
+		b.WithMultiline(0)
+	}
+	r := &renderer{h: b.Build(), out: out}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(files))
+	for i, name := range files {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = r.renderFile(name, *follow)
+		}(i, name)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		fail(err)
+	}
+}
+
+// parseColorMap parses the level color map of the -colormap flag: "default", "empty", or the comma-separated
+// level=color pairs overriding the default colors, the levels are read by otris.ParseLevel and the colors by otris.ParseColor.
+func parseColorMap(spec string) (otris.LevelColorMap, error) {
+	switch spec {
+	case "default":
+		return otris.DefaultColorMap, nil
+	case "empty":
+		return otris.EmptyColorMap, nil
+	}
+	m := maps.Clone(otris.DefaultColorMap)
+	for _, pair := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("unknown color map %q, want default, empty or level=color pairs", spec)
+		}
+		lvl, err := otris.ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		c, err := otris.ParseColor(value)
+		if err != nil {
+			return nil, err
+		}
+		m[lvl] = c
+	}
+	return m, nil
+}
+
+// renderer re-renders the log lines through the pretty handler.
+type renderer struct {
+	h   *otris.Handler
+	out *lockedWriter
+}
+
+func (r *renderer) renderFile(name string, follow bool) error {
+	if name == "-" {
+		return r.render(os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if follow {
+		return r.render(&followReader{f: f})
+	}
+	return r.render(f)
+}
+
+func (r *renderer) render(in io.Reader) error {
+	ctx := context.Background()
+	sc := otris.NewScanner(in, nil)
+	for sc.Scan() {
+		e, err := sc.Entry()
+		if err != nil {
+			if err := r.out.writeLine(sc.Line()); err != nil {
+				return err
+			}
+			continue
+		}
+		if !r.h.Enabled(ctx, e.Level) {
+			continue
+		}
+		if err := r.h.Handle(ctx, e.Record()); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// lockedWriter serializes the writes of the handler and the passed through lines.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// Fd returns the file descriptor of the underlying file, so the color mode can detect the terminal.
+func (w *lockedWriter) Fd() uintptr {
+	if f, ok := w.w.(interface{ Fd() uintptr }); ok {
+		return f.Fd()
+	}
+	return ^uintptr(0)
+}
+
+func (w *lockedWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.w.Write(line); err != nil {
+		return err
+	}
+	_, err := w.w.Write([]byte{'\n'})
+	return err
+}
+
+// followPollInterval is the interval of polling the followed file for new lines.
+const followPollInterval = 250 * time.Millisecond
+
+// followReader reads the file as it grows, like `tail -f`.
+// If the file is truncated, it is read again from the beginning.
+type followReader struct {
+	f      *os.File
+	offset int64
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		r.offset += int64(n)
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		time.Sleep(followPollInterval)
+		if fi, err := r.f.Stat(); err == nil && fi.Size() < r.offset {
+			if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+			r.offset = 0
+		}
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "otris:", err)
+	os.Exit(1)
+}
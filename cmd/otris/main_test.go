@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Totus-Floreo/otris"
+	"github.com/fatih/color"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestRenderer(buf *bytes.Buffer) *renderer {
+	out := &lockedWriter{w: buf}
+	h := otris.NewHandlerBuilder().
+		WithPretty().
+		WithWriter(out).
+		WithTimeLayout(time.TimeOnly).
+		WithColorMode(otris.ColorNever).
+		WithOptions(&slog.HandlerOptions{Level: otris.LevelInfo}).
+		Build()
+	return &renderer{h: h, out: out}
+}
+
+func TestRender(t *testing.T) {
+	in := `{"time":"2024-07-26T05:26:24Z","level":"INFO","msg":"started","port":8080}
+time=2024-07-26T05:26:25Z level=DEBUG msg=hidden
+panic: runtime error
+time=2024-07-26T05:26:26Z level=ERROR msg="query | failed" code=500
+
+	goroutine 1 [running]:
+`
+	var buf bytes.Buffer
+	if err := newTestRenderer(&buf).render(strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "05:26:24 | INFO | started | 8080\n" +
+		"panic: runtime error\n" +
+		"05:26:26 | ERROR | \"query | failed\" | 500\n" +
+		"\n" +
+		"\tgoroutine 1 [running]:\n"
+	if buf.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestParseColorMap(t *testing.T) {
+	hiRedBold, err := otris.ParseColor("hired+bold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		spec string
+		want map[slog.Level]otris.LogColor // the colors checked in the map
+		ok   bool
+	}{
+		{spec: "default", want: map[slog.Level]otris.LogColor{otris.LevelInfo: otris.DefaultColorMap[otris.LevelInfo]}, ok: true},
+		{spec: "empty", want: map[slog.Level]otris.LogColor{}, ok: true},
+		{spec: "info=green,error=hired+bold", want: map[slog.Level]otris.LogColor{
+			otris.LevelInfo:  otris.LogColor(color.FgGreen),
+			otris.LevelError: hiRedBold,
+			otris.LevelDebug: otris.DefaultColorMap[otris.LevelDebug],
+		}, ok: true},
+		{spec: "loud", ok: false},
+		{spec: "loud=red", ok: false},
+		{spec: "info=nocolor", ok: false},
+	}
+	for _, test := range cases {
+		t.Run(test.spec, func(t *testing.T) {
+			got, err := parseColorMap(test.spec)
+			if (err == nil) != test.ok {
+				t.Fatalf("\ngot  %v\nwant ok %t", err, test.ok)
+			}
+			if test.spec == "empty" && len(got) != 0 {
+				t.Errorf("\ngot  %v\nwant empty", got)
+			}
+			for lvl, c := range test.want {
+				if got[lvl] != c {
+					t.Errorf("\ngot  %d\nwant %d for %s", got[lvl], c, lvl)
+				}
+			}
+		})
+	}
+	if otris.DefaultColorMap[otris.LevelInfo] == otris.LogColor(color.FgGreen) {
+		t.Error("the default color map is modified")
+	}
+}
+
+func TestRenderFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("level=WARN msg=disk\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := newTestRenderer(&buf)
+	if err := r.renderFile(name, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "WARN | disk\n"; buf.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", buf.String(), want)
+	}
+	if err := r.renderFile(filepath.Join(t.TempDir(), "missing.log"), false); err == nil {
+		t.Error("\ngot  nil\nwant error")
+	}
+}
+
+func TestFollowReader(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("first\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r := &followReader{f: f}
+
+	read := func() string {
+		p := make([]byte, 64)
+		n, err := r.Read(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(p[:n])
+	}
+	if got := read(); got != "first\n" {
+		t.Errorf("\ngot  %q\nwant %q", got, "first\n")
+	}
+
+	// The reader waits for the file to grow.
+	go func() {
+		time.Sleep(followPollInterval / 2)
+		w, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return
+		}
+		defer w.Close()
+		w.WriteString("second\n")
+	}()
+	if got := read(); got != "second\n" {
+		t.Errorf("\ngot  %q\nwant %q", got, "second\n")
+	}
+
+	// The truncated file is read from the beginning.
+	if err := os.WriteFile(name, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "new\n" {
+		t.Errorf("\ngot  %q\nwant %q", got, "new\n")
+	}
+}
+
+func TestLockedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &lockedWriter{w: &buf}
+	if fd := w.Fd(); fd != ^uintptr(0) {
+		t.Errorf("\ngot  %d\nwant %d", fd, ^uintptr(0))
+	}
+
+	// The lines written concurrently are not interleaved.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if j%2 == 0 {
+					w.writeLine([]byte(fmt.Sprintf("line %d %d", i, j)))
+				} else {
+					fmt.Fprintf(w, "line %d %d\n", i, j)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("\ngot  %d\nwant %d", len(lines), 1000)
+	}
+	for _, line := range lines {
+		var i, j int
+		if n, err := fmt.Sscanf(line, "line %d %d", &i, &j); n != 2 || err != nil {
+			t.Errorf("\ngot  %q\nwant %q", line, "line i j")
+		}
+	}
+}
@@ -3,9 +3,19 @@ package otris
 import (
 	"github.com/fatih/color"
 	"log/slog"
+	"math"
 )
 
-// LogColor defines a single SGR Code
+// LogColor defines the SGR sequence of a color: a basic SGR code, e.g. color.FgRed or color.BgRed,
+// combined with the attributes (Bold, Dim, Italic, Underline), the 256-color or truecolor foreground
+// (Color256, RGB) and the background (WithBackground). The zero LogColor has no color.
+// The truecolor and the background colors need the 64-bit int, on the 32-bit platforms
+// RGB returns the nearest 256-color and WithBackground keeps the color unchanged.
+//
+// Usage:
+//
+//	LogColor(color.FgRed) | Bold
+//	RGB(255, 136, 0).WithBackground(Color256(236))
 type LogColor int
 
 type LevelColorMap map[slog.Level]LogColor
@@ -23,33 +33,196 @@ var DefaultColorMap = LevelColorMap{
 // EmptyColorMap is the empty color mapping used for safe logging.
 var EmptyColorMap = LevelColorMap{}
 
-//TODO WIP in v2 Coloring value in logs
-/*
+// DefaultKeyColor is the default color of the attribute keys in pretty mode.
+const DefaultKeyColor = LogColor(color.FgHiBlack)
+
+// DefaultRepeatColor is the default color of the repeat count of the dedup mode in pretty mode.
+const DefaultRepeatColor = LogColor(color.FgHiMagenta)
+
 // LogKey represents a key used for logging.
 type LogKey string
 
 // LogValue represents a value used for logging.
 type LogValue any
 
-// ColorMap represents a mapping of LogKey to a mapping of LogValue to LogColor.
+// ColorRange colors the integer values in the closed interval [Min, Max].
+type ColorRange struct {
+	Min   int64
+	Max   int64
+	Color LogColor
+}
+
+// ColorFunc colors the values for which Match reports true.
+type ColorFunc struct {
+	Match func(v slog.Value) bool
+	Color LogColor
+}
+
+// ValueColors holds the color rules for the values of a single LogKey.
+// Values are checked first, then Ranges and then Funcs, in order.
+type ValueColors struct {
+	Values map[LogValue]LogColor
+	Ranges []ColorRange
+	Funcs  []ColorFunc
+
+	indexed bool // the keys of Values are normalized by index, so the values are looked up in the map
+}
+
+// ColorMapV2 represents a mapping of LogKey to the color rules of its values.
 // It is used to define the color scheme for different log keys and values.
-type ColorMapV2 map[LogKey]map[LogValue]LogColor
+type ColorMapV2 map[LogKey]ValueColors
 
-// DefaultColorMap is the default color mapping used for logging.
-// TODO Implement SQL and HTTP error codes in v2
-var DefaultColorMapV2 = ColorMapV2{
-	LogKey(slog.LevelKey): {
-		LogValue(LevelFx):      LogColor(color.FgCyan),
-		LogValue(LevelFxError): LogColor(color.FgHiRed),
-		LogValue(LevelDebug):   LogColor(color.FgHiMagenta),
-		LogValue(LevelInfo):    LogColor(color.FgHiGreen),
-		LogValue(LevelWarning): LogColor(color.FgHiYellow),
-		LogValue(LevelError):   LogColor(color.FgRed),
+// HTTPCodeColors colors HTTP status codes by their class.
+var HTTPCodeColors = ValueColors{
+	Ranges: []ColorRange{
+		{Min: 100, Max: 199, Color: LogColor(color.FgCyan)},
+		{Min: 200, Max: 299, Color: LogColor(color.FgGreen)},
+		{Min: 300, Max: 399, Color: LogColor(color.FgBlue)},
+		{Min: 400, Max: 499, Color: LogColor(color.FgYellow)},
+		{Min: 500, Max: 599, Color: LogColor(color.FgRed)},
 	},
-	LogKey("httpcode"): {
-		LogValue(200): LogColor(color.FgGreen),
-		LogValue(404): LogColor(color.FgYellow),
-		LogValue(500): LogColor(color.FgRed),
+}
+
+// SQLStateColors colors SQLSTATE codes by their class:
+// "00" is a success, "01" and "02" are warnings and everything else is an error.
+var SQLStateColors = ValueColors{
+	Funcs: []ColorFunc{
+		{Match: sqlStateClass("00"), Color: LogColor(color.FgGreen)},
+		{Match: sqlStateClass("01", "02"), Color: LogColor(color.FgYellow)},
+		{Match: sqlStateClass(), Color: LogColor(color.FgRed)},
 	},
 }
-*/
+
+// DefaultColorMapV2 is the default value color mapping used for pretty logging.
+var DefaultColorMapV2 = ColorMapV2{
+	"httpcode": HTTPCodeColors,
+	"status":   HTTPCodeColors,
+	"sqlstate": SQLStateColors,
+}
+
+// Color returns the color of the value v logged under the given key.
+// It reports false if no rule of the map matches the value.
+func (m ColorMapV2) Color(key string, v slog.Value) (LogColor, bool) {
+	rules, ok := m[LogKey(key)]
+	if !ok {
+		return 0, false
+	}
+	return rules.Color(v)
+}
+
+// Color returns the color of the value v.
+// It reports false if no rule matches the value.
+func (c ValueColors) Color(v slog.Value) (LogColor, bool) {
+	if clr, ok := c.valueColor(v); ok {
+		return clr, true
+	}
+	if len(c.Ranges) > 0 {
+		if n, ok := intValue(v); ok {
+			for _, r := range c.Ranges {
+				if n >= r.Min && n <= r.Max {
+					return r.Color, true
+				}
+			}
+		}
+	}
+	for _, f := range c.Funcs {
+		if f.Match != nil && f.Match(v) {
+			return f.Color, true
+		}
+	}
+	return 0, false
+}
+
+// valueColor returns the color of the value v from Values.
+// The indexed values are looked up in the map, the others are compared one by one.
+func (c ValueColors) valueColor(v slog.Value) (LogColor, bool) {
+	if len(c.Values) == 0 {
+		return 0, false
+	}
+	if c.indexed {
+		if !indexedKind(v.Kind()) {
+			return 0, false
+		}
+		clr, ok := c.Values[v.Any()]
+		return clr, ok
+	}
+	for val, clr := range c.Values {
+		if slog.AnyValue(val).Equal(v) {
+			return clr, true
+		}
+	}
+	return 0, false
+}
+
+// index returns a copy of the map with the indexed rules, see ValueColors.index.
+func (m ColorMapV2) index() ColorMapV2 {
+	indexed := make(ColorMapV2, len(m))
+	for key, rules := range m {
+		indexed[key] = rules.index()
+	}
+	return indexed
+}
+
+// index returns a copy of the rules with the keys of Values normalized like slog.AnyValue does,
+// e.g. int to int64, so the values are looked up in the map instead of compared one by one.
+// The rules with the keys of the other kinds, like time.Time, are not indexed.
+func (c ValueColors) index() ValueColors {
+	if len(c.Values) == 0 || c.indexed {
+		return c
+	}
+	values := make(map[LogValue]LogColor, len(c.Values))
+	for val, clr := range c.Values {
+		v := slog.AnyValue(val)
+		if !indexedKind(v.Kind()) {
+			return c
+		}
+		values[v.Any()] = clr
+	}
+	c.Values, c.indexed = values, true
+	return c
+}
+
+// indexedKind reports whether the values of the kind are indexed, their slog.Value.Equal is the equality of Any.
+func indexedKind(k slog.Kind) bool {
+	switch k {
+	case slog.KindString, slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool, slog.KindDuration:
+		return true
+	}
+	return false
+}
+
+// intValue returns v as an int64 if v holds an integer.
+func intValue(v slog.Value) (int64, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return v.Int64(), true
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+// sqlStateClass returns a matcher for SQLSTATE codes of the given classes.
+// Without classes it matches any SQLSTATE code.
+func sqlStateClass(classes ...string) func(v slog.Value) bool {
+	return func(v slog.Value) bool {
+		if v.Kind() != slog.KindString {
+			return false
+		}
+		code := v.String()
+		if len(code) != 5 {
+			return false
+		}
+		if len(classes) == 0 {
+			return true
+		}
+		for _, class := range classes {
+			if code[:2] == class {
+				return true
+			}
+		}
+		return false
+	}
+}
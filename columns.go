@@ -0,0 +1,255 @@
+package otris
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Column describes a pretty column that is padded to a common width,
+// so consecutive lines line up under each other in a terminal.
+type Column struct {
+	Key   string // slog.LevelKey, slog.MessageKey or an attribute key, bare or prefixed with groups
+	Width int    // Fixed width of the column, 0 learns the width from the logged values
+	Max   int    // Maximum width of the column, longer values are truncated with an ellipsis, 0 means no limit
+}
+
+// DefaultColumns pads the level and the message to the learned widths.
+var DefaultColumns = []Column{
+	{Key: slog.LevelKey, Max: 8},
+	{Key: slog.MessageKey, Max: 48},
+}
+
+// ellipsis is appended to the truncated values.
+const ellipsis = "…"
+
+// column holds the width of a single column, it is shared between the handler and its clones.
+type column struct {
+	Column
+	learned atomic.Int64
+}
+
+// width returns the width to pad the value of width `w` to.
+// It learns the new width if the column is adaptive.
+func (c *column) width(w int) int {
+	if c.Width > 0 {
+		return c.Width
+	}
+	for {
+		old := c.learned.Load()
+		if int64(w) <= old {
+			return int(old)
+		}
+		if c.learned.CompareAndSwap(old, int64(w)) {
+			return w
+		}
+	}
+}
+
+// columnSet maps the column keys to the columns.
+type columnSet map[string]*column
+
+func newColumnSet(cols []Column) columnSet {
+	set := make(columnSet, len(cols))
+	for _, c := range cols {
+		if c.Max > 0 && c.Width > c.Max {
+			c.Width = c.Max
+		}
+		set[c.Key] = &column{Column: c}
+	}
+	return set
+}
+
+// isBuiltinKey reports whether the key is the key of a built-in attribute.
+func isBuiltinKey(key string) bool {
+	return key == slog.TimeKey || key == slog.LevelKey || key == slog.MessageKey || key == slog.SourceKey
+}
+
+// alignColumn pads or truncates the value written to the buffer since `start`
+// if the key belongs to a column.
+func (s *handleState) alignColumn(key string, start int) {
+	if !s.h.pretty || len(s.h.columns) == 0 || isBuiltinKey(key) != s.builtin {
+		return
+	}
+	col, ok := s.h.columns[key]
+	if !ok && s.prefix != nil && len(*s.prefix) > 0 {
+		col, ok = s.h.columns[string(*s.prefix)+key]
+	}
+	if !ok {
+		return
+	}
+	w := displayWidth((*s.buf)[start:])
+	if col.Max > 0 && w > col.Max {
+		*s.buf = append((*s.buf)[:start], truncateWidth((*s.buf)[start:], col.Max)...)
+		w = col.Max
+	}
+	for pad := col.width(w) - w; pad > 0; pad-- {
+		s.buf.WriteByte(' ')
+	}
+	s.padEnd = len(*s.buf)
+}
+
+// trimPadding removes the padding of the last column if nothing was written after it.
+func (s *handleState) trimPadding() {
+	if s.padEnd == 0 || s.padEnd != len(*s.buf) {
+		return
+	}
+	n := len(*s.buf)
+	for n > 0 && (*s.buf)[n-1] == ' ' {
+		n--
+	}
+	*s.buf = (*s.buf)[:n]
+}
+
+// displayWidth returns the number of terminal cells needed to display b.
+// ANSI escape sequences take no cells and wide characters take two cells.
+func displayWidth(b []byte) int {
+	w := 0
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		w += runeWidth(r)
+		i += size
+	}
+	return w
+}
+
+// truncateWidth returns a copy of b truncated to `max` cells with the trailing ellipsis.
+// ANSI escape sequences are kept, so the colors are still reset after the truncated value.
+func truncateWidth(b []byte, max int) []byte {
+	out := make([]byte, 0, len(b))
+	w, cut := 0, false
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			out = append(out, b[i:i+n]...)
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		if !cut {
+			if rw := runeWidth(r); w+rw <= max-1 {
+				out = append(out, b[i:i+size]...)
+				w += rw
+			} else {
+				out = append(out, ellipsis...)
+				cut = true
+			}
+		}
+		i += size
+	}
+	return out
+}
+
+// escapeLen returns the length of the ANSI escape sequence at the start of b, or 0.
+func escapeLen(b []byte) int {
+	if len(b) < 2 || b[0] != '\x1b' {
+		return 0
+	}
+	if b[1] != '[' {
+		return 2
+	}
+	// CSI sequence ends with a byte in the range 0x40–0x7E.
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// runeWidth returns the number of terminal cells needed to display r.
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRanges are the main East Asian Wide and Fullwidth ranges and the emoji blocks.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f5},
+	{0x26fa, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+// isWide reports whether r takes two terminal cells.
+func isWide(r rune) bool {
+	if r < wideRanges[0][0] {
+		return false
+	}
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch {
+		case r < wideRanges[m][0]:
+			hi = m
+		case r > wideRanges[m][1]:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+package otris
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the output format of the Handler.
+type Format string
+
+const (
+	FormatStruct Format = "struct"
+	FormatPretty Format = "pretty"
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+	// FormatAuto is pretty on the terminals and json otherwise, see HandlerBuilder.WithAutoFormat.
+	FormatAuto Format = "auto"
+)
+
+// Output targets of the Config, any other output is the path of the file the records are appended to.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Config describes the Handler, it can be loaded from the environment, JSON and key=value files,
+// so the format can be switched without rebuilding, e.g. with OTRIS_FORMAT=json.
+// The zero values keep the defaults of NewHandlerBuilder.
+//
+// The keys of the files and the suffixes of the environment variables are:
+//
+//	format       struct, pretty, json, logfmt or auto
+//	level        the minimum level, parsed with ParseLevel, e.g. "debug" or "INFO+2"
+//	colors       the colors of the levels, parsed with ParseColor, e.g. "info=green,error=hired"
+//	time_layout  the time layout, e.g. "15:04:05", or the name of a time package layout, e.g. "RFC3339"
+//	separator    the separator of the attributes, the quoted values are unquoted, e.g. " | "
+//	source       add the source of the records
+//	output       stdout, stderr or the path of the file
+//	insecure     don't escape the strings
+//
+// The file of the output is a RotatingFile, it's rotated with the keys:
+//
+//	max_size     the maximum size of the file, e.g. "100MB", the suffixes are KB, MB and GB
+//	max_backups  the maximum number of the rotated files
+//	max_age      the maximum age of the rotated files, e.g. "168h"
+//	interval     the interval of the rotation, e.g. "24h"
+//	compress     compress the rotated files with gzip
+//	reopen       reopen the file on SIGHUP
+//
+// Usage:
+//
+//	cfg, err := otris.FromEnv("OTRIS")
+//	if err != nil {
+//		return err
+//	}
+//	handler, err := cfg.Build()
+type Config struct {
+	Format     Format            `json:"format,omitempty"`
+	Level      string            `json:"level,omitempty"`
+	Colors     map[string]string `json:"colors,omitempty"`
+	TimeLayout string            `json:"time_layout,omitempty"`
+	Separator  string            `json:"separator,omitempty"`
+	Source     bool              `json:"source,omitempty"`
+	Output     string            `json:"output,omitempty"`
+	Insecure   bool              `json:"insecure,omitempty"`
+
+	MaxSize    string `json:"max_size,omitempty"`
+	MaxBackups int    `json:"max_backups,omitempty"`
+	MaxAge     string `json:"max_age,omitempty"`
+	Interval   string `json:"interval,omitempty"`
+	Compress   bool   `json:"compress,omitempty"`
+	Reopen     bool   `json:"reopen,omitempty"`
+}
+
+// configKeys are the keys of the files and the suffixes of the environment variables.
+var configKeys = []string{
+	"format", "level", "colors", "time_layout", "separator", "source", "output", "insecure",
+	"max_size", "max_backups", "max_age", "interval", "compress", "reopen",
+}
+
+// timeLayouts are the named layouts of the time package.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"DateOnly":    time.DateOnly,
+	"DateTime":    time.DateTime,
+	"Kitchen":     time.Kitchen,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"Stamp":       time.Stamp,
+	"StampMicro":  time.StampMicro,
+	"StampMilli":  time.StampMilli,
+	"StampNano":   time.StampNano,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// FromEnv returns the Config from the environment variables with the prefix, e.g. OTRIS_FORMAT for "OTRIS".
+func FromEnv(prefix string) (Config, error) {
+	var c Config
+	err := c.LoadEnv(prefix)
+	return c, err
+}
+
+// FromFile returns the Config from the file, the ".json" files are decoded as JSON
+// and the other files as key=value lines.
+func FromFile(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = c.LoadJSON(data)
+	} else {
+		err = c.LoadKeyValue(data)
+	}
+	return c, err
+}
+
+// LoadEnv overrides the fields of the Config with the set environment variables with the prefix.
+func (c *Config) LoadEnv(prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	var errs []error
+	for _, key := range configKeys {
+		if value, ok := os.LookupEnv(prefix + strings.ToUpper(key)); ok {
+			errs = append(errs, c.set(key, value))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoadJSON overrides the fields of the Config with the fields of the JSON object, the unknown fields are errors.
+func (c *Config) LoadJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("otris: config: %w", err)
+	}
+	return nil
+}
+
+// LoadKeyValue overrides the fields of the Config with the `key = value` lines,
+// the empty lines and the lines starting with '#' are skipped.
+func (c *Config) LoadKeyValue(data []byte) error {
+	var errs []error
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			errs = append(errs, fmt.Errorf("otris: config: line %d: missing '='", n))
+			continue
+		}
+		if err := c.set(strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+		}
+	}
+	return errors.Join(append(errs, sc.Err())...)
+}
+
+// set sets the field of the key from the text value.
+func (c *Config) set(key, value string) error {
+	if len(value) > 1 && (value[0] == '"' || value[0] == '`') {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return fmt.Errorf("otris: config: %s: invalid quoted value %s", key, value)
+		}
+		value = unquoted
+	}
+	var err error
+	switch key {
+	case "format":
+		c.Format = Format(strings.ToLower(value))
+	case "level":
+		c.Level = value
+	case "colors":
+		c.Colors, err = parseColors(value)
+	case "time_layout":
+		c.TimeLayout = value
+	case "separator":
+		c.Separator = value
+	case "source":
+		c.Source, err = strconv.ParseBool(value)
+	case "output":
+		c.Output = value
+	case "insecure":
+		c.Insecure, err = strconv.ParseBool(value)
+	case "max_size":
+		c.MaxSize = value
+	case "max_backups":
+		c.MaxBackups, err = strconv.Atoi(value)
+	case "max_age":
+		c.MaxAge = value
+	case "interval":
+		c.Interval = value
+	case "compress":
+		c.Compress, err = strconv.ParseBool(value)
+	case "reopen":
+		c.Reopen, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("otris: config: unknown key %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("otris: config: %s: %w", key, err)
+	}
+	return nil
+}
+
+// parseColors parses the colors of the levels like "info=green,error=hired".
+func parseColors(value string) (map[string]string, error) {
+	colors := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		level, color, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing '=' in %q", pair)
+		}
+		colors[strings.TrimSpace(level)] = strings.TrimSpace(color)
+	}
+	return colors, nil
+}
+
+// Validate reports all invalid fields of the Config.
+func (c *Config) Validate() error {
+	var errs []error
+	switch c.Format {
+	case "", FormatStruct, FormatPretty, FormatJSON, FormatLogfmt, FormatAuto:
+	default:
+		errs = append(errs, fmt.Errorf("otris: config: unknown format %q", c.Format))
+	}
+	if c.Level != "" {
+		if _, err := ParseLevel(c.Level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := c.colorMap(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.rotateOptions(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// colorMap returns the LevelColorMap of the colors, nil if there are no colors.
+func (c *Config) colorMap() (LevelColorMap, error) {
+	if len(c.Colors) == 0 {
+		return nil, nil
+	}
+	levels := make([]string, 0, len(c.Colors))
+	for level := range c.Colors {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+
+	var errs []error
+	m := make(LevelColorMap, len(c.Colors))
+	for _, level := range levels {
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		color, err := ParseColor(c.Colors[level])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m[lvl] = color
+	}
+	return m, errors.Join(errs...)
+}
+
+// Builder validates the Config and returns the HandlerBuilder configured by it,
+// the RotatingFile of the output is opened in the append mode.
+func (c *Config) Builder() (*HandlerBuilder, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	w, err := c.writer()
+	if err != nil {
+		return nil, err
+	}
+	b := NewHandlerBuilder().WithWriter(w)
+	switch c.Format {
+	case FormatPretty:
+		b.WithPretty()
+	case FormatJSON:
+		b.WithJSON()
+	case FormatLogfmt:
+		b.WithLogfmt()
+	case FormatAuto:
+		b.WithAutoFormat(FormatJSON)
+	}
+	colors, _ := c.colorMap()
+	b.WithColor(colors)
+
+	layout := c.TimeLayout
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+	b.WithTimeLayout(layout)
+	b.WithSeparator(c.Separator)
+	if c.Insecure {
+		b.WithInsecure()
+	}
+
+	opts := &slog.HandlerOptions{AddSource: c.Source}
+	if c.Level != "" {
+		opts.Level, _ = ParseLevel(c.Level)
+	}
+	return b.WithOptions(opts), nil
+}
+
+// Build validates the Config and returns the Handler configured by it.
+func (c *Config) Build() (*Handler, error) {
+	b, err := c.Builder()
+	if err != nil {
+		return nil, err
+	}
+	return b.Build(), nil
+}
+
+// writer returns the writer of the output.
+func (c *Config) writer() (io.Writer, error) {
+	switch c.Output {
+	case "", OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	default:
+		opts, _ := c.rotateOptions()
+		f, err := NewRotatingFile(c.Output, opts)
+		if err != nil {
+			return nil, fmt.Errorf("otris: config: %w", err)
+		}
+		return f, nil
+	}
+}
+
+// rotateOptions returns the RotateOptions of the output file.
+func (c *Config) rotateOptions() (RotateOptions, error) {
+	opts := RotateOptions{MaxBackups: c.MaxBackups, Compress: c.Compress, ReopenOnSIGHUP: c.Reopen}
+	var errs []error
+	if c.MaxSize != "" {
+		size, err := parseSize(c.MaxSize)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("otris: config: max_size: %w", err))
+		}
+		opts.MaxSize = size
+	}
+	for _, d := range []struct {
+		key   string
+		value string
+		dst   *time.Duration
+	}{
+		{key: "max_age", value: c.MaxAge, dst: &opts.MaxAge},
+		{key: "interval", value: c.Interval, dst: &opts.Interval},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("otris: config: %s: %w", d.key, err))
+		}
+		*d.dst = v
+	}
+	return opts, errors.Join(errs...)
+}
+
+// parseSize parses the size in bytes with the optional KB, MB or GB suffix, e.g. "100MB".
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if n, ok := strings.CutSuffix(upper, u.suffix); ok {
+			upper, unit = strings.TrimSpace(n), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
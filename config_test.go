@@ -0,0 +1,106 @@
+package otris
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("OTRIS_FORMAT", "JSON")
+	t.Setenv("OTRIS_LEVEL", "warn")
+	t.Setenv("OTRIS_COLORS", "info=green, error=hi-red")
+	t.Setenv("OTRIS_SEPARATOR", `" | "`)
+	t.Setenv("OTRIS_SOURCE", "true")
+
+	cfg, err := FromEnv("OTRIS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		Format:    FormatJSON,
+		Level:     "warn",
+		Colors:    map[string]string{"info": "green", "error": "hi-red"},
+		Separator: " | ",
+		Source:    true,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("\ngot  %+v\nwant %+v", cfg, want)
+	}
+
+	t.Setenv("OTRIS_SOURCE", "maybe")
+	if _, err := FromEnv("OTRIS_"); err == nil {
+		t.Error("FromEnv doesn't return an error for the invalid bool")
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	kv := filepath.Join(dir, "otris.conf")
+	js := filepath.Join(dir, "otris.json")
+	os.WriteFile(kv, []byte("# logging\nformat = logfmt\nlevel = DEBUG+2\n\ntime_layout = RFC3339\n"), 0o644)
+	os.WriteFile(js, []byte(`{"format":"logfmt","level":"DEBUG+2","time_layout":"RFC3339"}`), 0o644)
+
+	want := Config{Format: FormatLogfmt, Level: "DEBUG+2", TimeLayout: "RFC3339"}
+	for _, path := range []string{kv, js} {
+		cfg, err := FromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s\ngot  %+v\nwant %+v", filepath.Base(path), cfg, want)
+		}
+	}
+
+	var cfg Config
+	if err := cfg.LoadKeyValue([]byte("format = json\ncolour = red\nlevel\n")); err == nil ||
+		!strings.Contains(err.Error(), `unknown key "colour"`) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := cfg.LoadJSON([]byte(`{"colour":"red"}`)); err == nil {
+		t.Error("LoadJSON doesn't return an error for the unknown field")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{Format: "yaml", Level: "loud", Colors: map[string]string{"info": "pink", "nope": "red"}, MaxSize: "lots", MaxAge: "7d"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate doesn't return an error")
+	}
+	for _, want := range []string{`unknown format "yaml"`, `unknown level "loud"`, `unknown color "pink"`, `unknown level "nope"`, `invalid size "lots"`, "max_age"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("the error %q doesn't contain %q", err, want)
+		}
+	}
+	if _, err := cfg.Build(); err == nil {
+		t.Error("Build doesn't return an error for the invalid Config")
+	}
+}
+
+func TestConfigBuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := Config{Format: FormatJSON, Level: "warn", Output: path, TimeLayout: "RFC3339", MaxSize: "1MB", MaxBackups: 3}
+	h, err := cfg.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h)
+	logger.Info("hidden")
+	logger.Warn("message", "a", 1)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"WARN","msg":"message","a":1}` + "\n"
+	if got := removeJSONTime(string(data)); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package otris
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextExtractor pulls the attributes out of the context, e.g. request, user, tenant or trace IDs.
+// The extracted attributes are appended to every record after the built-in attributes, they are not in a group.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+// ContextValue returns a ContextExtractor that appends the value stored in the context under the key
+// as the attribute with the given name. The nil values are skipped.
+//
+// Usage:
+//
+//	handler := NewHandlerBuilder().WithContextExtractor(ContextValue(requestIDKey{}, "request_id")).Build()
+func ContextValue(key any, name string) ContextExtractor {
+	return func(ctx context.Context) []slog.Attr {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		return []slog.Attr{slog.Any(name, v)}
+	}
+}
+
+// appendContextAttrs appends the attributes of the context extractors.
+func (s *handleState) appendContextAttrs(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	for _, extract := range s.h.extractors {
+		for _, a := range extract(ctx) {
+			s.appendAttr(a)
+		}
+	}
+}
+
+type contextAttrsKey struct{}
+
+type contextLoggerKey struct{}
+
+// WithContextAttrs returns a copy of ctx carrying the attributes in addition to the attributes of ctx.
+// The Handler appends them to every record logged with the returned context, like the attributes of the record,
+// so they are placed after the attributes from WithAttrs and inside the groups from WithGroup.
+//
+// Usage:
+//
+//	ctx = otris.WithContextAttrs(ctx, slog.String("request_id", id))
+//	logger.InfoContext(ctx, "request started")
+func WithContextAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	prev := ContextAttrs(ctx)
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, contextAttrsKey{}, all)
+}
+
+// ContextAttrs returns the attributes carried by ctx, the returned slice must not be modified.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// NewContext returns a copy of ctx carrying the logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextLoggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextLoggerKey{}).(*slog.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,123 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Totus-Floreo/otris/internal/slog/buffer"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// DefaultDedupWindow is the default window of the dedup mode.
+const DefaultDedupWindow = 10 * time.Second
+
+// RepeatedKey is the key of the repeat count in the summary records of the dedup mode, except pretty mode.
+const RepeatedKey = "repeated"
+
+// dedupState is the state of the dedup mode, it's shared by the Handler and its clones and guarded by their mu.
+type dedupState struct {
+	window time.Duration
+	key    []byte      // the last written record without the time
+	level  slog.Level  // level of the last written record
+	start  time.Time   // time of the last written record, the window starts with it
+	last   time.Time   // time of the last suppressed record, the time of the summary
+	count  int         // number of the suppressed records
+	timer  *time.Timer // writes the summary when the window is over, started by the first suppressed record
+}
+
+// writeDeduped writes the formatted record unless it repeats the last written record within the window.
+// The pending summary is written before the record. mu must be locked.
+func (h *Handler) writeDeduped(line []byte, keyStart int, record slog.Record) error {
+	d := h.dedup
+	t := record.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	key := line[keyStart:]
+	if elapsed := t.Sub(d.start); bytes.Equal(key, d.key) && elapsed < d.window {
+		if d.count == 0 {
+			h.startDedupTimer(d.window - elapsed)
+		}
+		d.count++
+		d.last = t
+		return nil
+	}
+	err := h.writeRepeated()
+	d.key = append(d.key[:0], key...)
+	d.level, d.start = record.Level, t
+	_, werr := h.w.Write(line)
+	return errors.Join(err, werr)
+}
+
+// writeRepeated writes the summary record of the suppressed records if there are any. mu must be locked.
+func (h *Handler) writeRepeated() error {
+	d := h.dedup
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.count == 0 {
+		return nil
+	}
+	// The summary is not in the groups and has no attributes of the handler.
+	root := h.clone()
+	root.preformattedAttrs, root.preformattedTail = nil, nil
+	root.groupPrefix, root.groups, root.nOpenGroups = "", nil, 0
+
+	r := slog.NewRecord(d.last, d.level, repeatedMessage(d.count).String(), 0)
+	if !h.pretty {
+		r.AddAttrs(slog.Int(RepeatedKey, d.count))
+	}
+	state := root.newHandleState(buffer.New(), true, "")
+	defer state.free()
+	state.repeated = d.count
+	root.format(&state, context.Background(), r, nil)
+
+	// The records after the summary are not suppressed until one of them is written.
+	d.key, d.count = d.key[:0], 0
+	_, err := h.w.Write(*state.buf)
+	return err
+}
+
+// startDedupTimer starts the timer writing the summary when the window is over,
+// so it's written even if nothing is logged after. mu must be locked.
+func (h *Handler) startDedupTimer(after time.Duration) {
+	d := h.dedup
+	var timer *time.Timer
+	timer = time.AfterFunc(after, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		// The summary is already written if the timer is stopped or replaced.
+		if d.timer == timer {
+			_ = h.writeRepeated()
+		}
+	})
+	d.timer = timer
+}
+
+// repeatedMessage is the message of the summary record in pretty mode, ReplaceAttr sees it as a fmt.Stringer.
+type repeatedMessage int
+
+func (n repeatedMessage) String() string {
+	return "last message repeated " + strconv.Itoa(int(n)) + " times"
+}
+
+// appendRepeated appends the message of the summary record in pretty mode, the count is in the repeat color.
+func (s *handleState) appendRepeated(n repeatedMessage) {
+	s.appendString("last message repeated ")
+	set := s.setColor(s.h.repeatColor)
+	*s.buf = strconv.AppendInt(*s.buf, int64(n), 10)
+	s.unsetColor(set)
+	s.appendString(" times")
+}
+
+// flushDedup writes the summary of the records suppressed by the dedup mode.
+func (h *Handler) flushDedup() {
+	if h.dedup != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		_ = h.writeRepeated()
+	}
+}
@@ -0,0 +1,150 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"github.com/fatih/color"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHandlerBuilderWithDedup(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithWriter(&buf).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).
+		WithDedup(time.Hour).Build()
+	logger := slog.New(h)
+
+	for i := 0; i < 3; i++ {
+		logger.Info("a", "k", 1)
+	}
+	logger.Info("a", "k", 2)
+	logger.Info("b")
+	// The clones share the state, the group without attributes is not rendered.
+	logger.WithGroup("g").Info("b")
+	logger.With("x", 1).Info("b")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "level=INFO msg=a k=1\n" +
+		"level=INFO msg=\"last message repeated 2 times\" repeated=2\n" +
+		"level=INFO msg=a k=2\n" +
+		"level=INFO msg=b\n" +
+		"level=INFO msg=\"last message repeated 1 times\" repeated=1\n" +
+		"level=INFO msg=b x=1\n"
+	if buf.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestHandlerDedupWindow(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithJSON().WithWriter(&buf).WithDedup(time.Second).Build()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, d := range []time.Duration{0, 500 * time.Millisecond, 900 * time.Millisecond, 2 * time.Second} {
+		if err := h.Handle(context.Background(), slog.NewRecord(start.Add(d), slog.LevelWarn, "retry", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"time":"2024-05-01T12:00:00Z","level":"WARN","msg":"retry"}` + "\n" +
+		`{"time":"2024-05-01T12:00:00.9Z","level":"WARN","msg":"last message repeated 2 times","repeated":2}` + "\n" +
+		`{"time":"2024-05-01T12:00:02Z","level":"WARN","msg":"retry"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestHandlerDedupPretty(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithPretty().WithWriter(&buf).WithColor(EmptyColorMap).WithColorMode(ColorAlways).
+		WithRepeatColor(LogColor(color.FgHiRed)).WithTimeLayout(time.TimeOnly).WithDedup(0).Build()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if err := h.Handle(context.Background(), slog.NewRecord(start.Add(time.Duration(i)*time.Second), slog.LevelInfo, "message", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "12:00:00 | \x1b[37mINFO\x1b[0m | message\n" +
+		"12:00:03 | \x1b[37mINFO\x1b[0m | last message repeated \x1b[91m3\x1b[0m times\n"
+	if buf.String() != want {
+		t.Errorf("\ngot  %q\nwant %q", buf.String(), want)
+	}
+}
+
+func TestHandlerDedupPrettyReplaceAttr(t *testing.T) {
+	cases := []struct {
+		name    string
+		replace func([]string, slog.Attr) slog.Attr
+		want    string
+	}{
+		{"remove time", removeTime, "last message repeated \x1b[" + strconv.Itoa(int(DefaultRepeatColor)) + "m2\x1b[0m times"},
+		{"string message", func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.MessageKey {
+				return slog.String(a.Key, a.Value.String())
+			}
+			return removeTime(groups, a)
+		}, "last message repeated 2 times"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewHandlerBuilder().WithPretty().WithWriter(&buf).WithColor(EmptyColorMap).WithColorMode(ColorAlways).
+				WithOptions(&slog.HandlerOptions{ReplaceAttr: c.replace}).WithDedup(0).Build()
+			for i := 0; i < 3; i++ {
+				if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "message", 0)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := h.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "\x1b[37mINFO\x1b[0m | message\n\x1b[37mINFO\x1b[0m | " + c.want + "\n"
+			if buf.String() != want {
+				t.Errorf("\ngot  %q\nwant %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestHandlerDedupTimer(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithWriter(&buf).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).
+		WithDedup(100 * time.Millisecond).Build()
+	logger := slog.New(h)
+
+	// The summary is written by the timer when the window is over, without the next record.
+	output := func() string {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return buf.String()
+	}
+	for i := 0; i < 3; i++ {
+		logger.Info("a")
+	}
+	want := "level=INFO msg=a\n" +
+		"level=INFO msg=\"last message repeated 2 times\" repeated=2\n"
+	for deadline := time.Now().Add(5 * time.Second); output() != want && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if got := output(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+
+	// The record after the summary is written.
+	logger.Info("a")
+	want += "level=INFO msg=a\n"
+	if got := output(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -94,6 +94,14 @@ func (l *SlogLogger) logError(msg string, fields ...any) {
 	l.Logger.Log(l.ctx, lvl, msg, l.filter(fields)...)
 }
 
+// flush flushes the handler of the logger if it implements otris.Lifecycle,
+// it's called on the last events of the application, so the shutdown logs are not lost.
+func (l *SlogLogger) flush() {
+	if h, ok := l.Logger.Handler().(otris.Lifecycle); ok {
+		_ = h.Sync()
+	}
+}
+
 // LogEvent logs the given event to the provided slog logger.
 func (l *SlogLogger) LogEvent(event fxevent.Event) {
 	switch e := event.(type) {
@@ -243,6 +251,7 @@ func (l *SlogLogger) LogEvent(event fxevent.Event) {
 		if e.Err != nil {
 			l.logError("stop failed", slogErr(e.Err))
 		}
+		l.flush()
 
 	case *fxevent.RollingBack:
 		l.logError("start failed, rolling back", slogErr(e.StartErr))
@@ -251,10 +260,13 @@ func (l *SlogLogger) LogEvent(event fxevent.Event) {
 		if e.Err != nil {
 			l.logError("rollback failed", slogErr(e.Err))
 		}
+		l.flush()
 
 	case *fxevent.Started:
 		if e.Err != nil {
 			l.logError("start failed", slogErr(e.Err))
+			// The failed start is the last event after the rollback.
+			l.flush()
 		} else {
 			l.logEvent("started")
 		}
@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+	"log/slog"
+)
+
+// LevelsHandler provides *otris.LevelsHandler to view and change the levels of the handlers at runtime.
+// It is built from the *slog.LevelVar and *otris.ModuleLevels of the application, both are optional.
+//
+// Usage:
+//
+//	fx.New(
+//		fx.Supply(level, modules),
+//		otrisfx.LevelsHandler,
+//		fx.Invoke(func(mux *http.ServeMux, h *otris.LevelsHandler) { mux.Handle("/log/level", h) }),
+//	)
+var LevelsHandler = fx.Provide(newLevelsHandler)
+
+type levelsHandlerParams struct {
+	fx.In
+
+	Level   *slog.LevelVar      `optional:"true"`
+	Modules *otris.ModuleLevels `optional:"true"`
+}
+
+func newLevelsHandler(p levelsHandlerParams) *otris.LevelsHandler {
+	return otris.NewLevelsHandler(p.Level, p.Modules)
+}
@@ -0,0 +1,57 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelsHandler(t *testing.T) {
+	level := new(slog.LevelVar)
+	modules, err := otris.NewModuleLevels("*=info,db=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		options []fx.Option
+		want    string
+	}{
+		{"without levels", nil, "{}\n"},
+		{"level", []fx.Option{fx.Supply(level)}, `{"level":"INFO"}` + "\n"},
+		{"level and modules", []fx.Option{fx.Supply(level, modules)}, `{"level":"INFO","modules":{"*":"INFO","db":"DEBUG"}}` + "\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var h *otris.LevelsHandler
+			app := fxtest.New(t, fx.NopLogger, fx.Options(c.options...), LevelsHandler, fx.Populate(&h))
+			app.RequireStart()
+			defer app.RequireStop()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+			if rec.Code != http.StatusOK || rec.Body.String() != c.want {
+				t.Errorf("\ngot  %d %s\nwant %d %s", rec.Code, rec.Body.String(), http.StatusOK, c.want)
+			}
+		})
+	}
+
+	// The handler changes the supplied level.
+	var h *otris.LevelsHandler
+	app := fxtest.New(t, fx.NopLogger, fx.Supply(level), LevelsHandler, fx.Populate(&h))
+	app.RequireStart()
+	defer app.RequireStop()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"DEBUG"}`)))
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("\ngot  %s\nwant %s", level.Level(), slog.LevelDebug)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"context"
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+)
+
+// NewHandler builds the *otris.Handler and registers the OnStop hook that flushes and syncs it.
+// The OnStop hooks run in the reverse order of their registration, so the hook runs after the hooks of the
+// components constructed after the handler, e.g. the components that depend on the handler or the logger.
+// The logs of the hooks registered before the handler is constructed, e.g. by the components that use
+// slog.Default, are written after the sync. SlogLogger syncs the handler again on the Stopped event,
+// and since the fx event logger is constructed first, the handler of Module is constructed before every component
+// unless Config.NoEventLogger is set.
+func NewHandler(lc fx.Lifecycle, b *otris.HandlerBuilder) *otris.Handler {
+	h := b.Build()
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return h.Sync()
+		},
+	})
+	return h
+}
+
+// newOwnedHandler builds the *otris.Handler that owns its writer and registers the OnStop hook that closes it,
+// e.g. the rotating file opened by otris.Config. The writer isn't closed if it's os.Stdout or os.Stderr.
+func newOwnedHandler(lc fx.Lifecycle, b *otris.HandlerBuilder) *otris.Handler {
+	h := b.Build()
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return h.Close()
+		},
+	})
+	return h
+}
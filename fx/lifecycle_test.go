@@ -0,0 +1,166 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/fx/fxtest"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncRecorder records the lines written to it and the calls of Sync in order.
+type syncRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *syncRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (r *syncRecorder) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "sync")
+	return nil
+}
+
+func (r *syncRecorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+func removeTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
+
+func newTestBuilder(w *syncRecorder, level slog.Level) *otris.HandlerBuilder {
+	return otris.NewHandlerBuilder().WithWriter(w).WithOptions(&slog.HandlerOptions{Level: level, ReplaceAttr: removeTime})
+}
+
+// logOnStop logs the message in the OnStop hook, like the components that log their shutdown.
+func logOnStop(msg string) func(fx.Lifecycle, *slog.Logger) {
+	return func(lc fx.Lifecycle, logger *slog.Logger) {
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				logger.Info(msg)
+				return nil
+			},
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	w := new(syncRecorder)
+	app := fxtest.New(t,
+		fx.NopLogger,
+		fx.Supply(newTestBuilder(w, slog.LevelInfo)),
+		fx.Provide(NewHandler, NewLogger),
+		fx.Invoke(logOnStop("stopping")),
+	)
+	app.RequireStart().RequireStop()
+
+	// The hook of the handler runs after the hooks of the components built with it.
+	got, want := strings.Join(w.Events(), "\n"), "level=INFO msg=stopping\nsync"
+	if got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestNewHandlerOrder(t *testing.T) {
+	cases := []struct {
+		name          string
+		noEventLogger bool
+		want          string
+	}{
+		// The hook of the component is registered before the handler, its log is written after the sync.
+		{"no event logger", true, "level=INFO msg=stopping\nsync\nlevel=INFO msg=early"},
+		// The event logger constructs the handler before the invocations, the Stopped event syncs it again.
+		{"event logger", false, "level=INFO msg=stopping\nlevel=INFO msg=early\nsync\nsync"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := new(syncRecorder)
+			var early *slog.Logger // the logger of the component constructed before the handler
+			app := fxtest.New(t,
+				fx.NopLogger,
+				Module(Config{Builder: newTestBuilder(w, slog.LevelInfo), NoEventLogger: c.noEventLogger}),
+				fx.Invoke(func(lc fx.Lifecycle) {
+					lc.Append(fx.Hook{
+						OnStop: func(context.Context) error {
+							early.Info("early")
+							return nil
+						},
+					})
+				}),
+				fx.Invoke(func(logger *slog.Logger) { early = logger }),
+				fx.Invoke(logOnStop("stopping")),
+			)
+			app.RequireStart().RequireStop()
+
+			if got := strings.Join(w.Events(), "\n"); got != c.want {
+				t.Errorf("\ngot  %s\nwant %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSlogLoggerFlush(t *testing.T) {
+	cases := []struct {
+		name    string
+		onStart func(context.Context) error
+		last    string // the last line before the flush
+	}{
+		{"stopped", nil, `level=FX msg="OnStop hook executed"`},
+		{"rolled back", func(context.Context) error { return errors.New("failed") }, `level=FXError msg="start failed" error=failed`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := new(syncRecorder)
+			// The handler has no OnStop hook, only the event logger syncs it.
+			logger := slog.New(newTestBuilder(w, otris.LevelFx).Build())
+			app := fxtest.New(t,
+				fx.WithLogger(func() fxevent.Logger { return NewSlogLogger(logger) }),
+				fx.Supply(logger),
+				fx.Invoke(logOnStop("stopping")),
+				fx.Invoke(func(lc fx.Lifecycle) {
+					lc.Append(fx.Hook{OnStart: c.onStart})
+				}),
+			)
+			if err := app.Start(context.Background()); err != nil {
+				if c.onStart == nil {
+					t.Fatal(err)
+				}
+			} else {
+				app.RequireStop()
+			}
+
+			// The handler is synced after the logs of the hooks and the last event.
+			events := w.Events()
+			if len(events) < 2 || events[len(events)-1] != "sync" {
+				t.Fatalf("\ngot  %q\nwant sync at the end", events)
+			}
+			if got := events[len(events)-2]; !strings.HasPrefix(got, c.last) {
+				t.Errorf("\ngot  %s\nwant %s", got, c.last)
+			}
+			if i := slices.Index(events, "level=INFO msg=stopping"); i < 0 || !slices.Contains(events[i:], "sync") {
+				t.Errorf("\ngot  %q\nwant %q before sync", events, "level=INFO msg=stopping")
+			}
+		})
+	}
+}
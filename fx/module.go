@@ -0,0 +1,93 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"log/slog"
+)
+
+// Config configures the otris Module.
+type Config struct {
+	// Builder builds the handler, it takes precedence over Handler.
+	Builder *otris.HandlerBuilder
+	// Handler configures the handler if Builder is nil, e.g. from otris.FromEnv.
+	// otris.NewHandlerBuilder() is used if both are nil.
+	Handler *otris.Config
+	// Loggers are the names of the child loggers, they are provided as *slog.Logger with the `name:"<name>"` tag
+	// and the otris.ModuleKey attribute, so their levels can be set with otris.ModuleLevels.
+	Loggers []string
+	// SetDefault sets the logger as slog.Default.
+	SetDefault bool
+	// NoEventLogger disables SlogLogger as the fx event logger.
+	NoEventLogger bool
+}
+
+// Module provides *otris.Handler, *slog.Logger and the named child loggers configured by cfg,
+// and installs SlogLogger as the fx event logger. The handler is flushed when the application stops,
+// and the handler configured by cfg.Handler is also closed to release its file and goroutines.
+//
+// Usage:
+//
+//	fx.New(
+//		otrisfx.Module(otrisfx.Config{
+//			Builder: otris.NewHandlerBuilder().WithPretty(),
+//			Loggers: []string{"db", "http"},
+//		}),
+//		fx.Invoke(func(p struct {
+//			fx.In
+//			Logger *slog.Logger `name:"db"`
+//		}) {
+//			p.Logger.Info("connected")
+//		}),
+//	)
+func Module(cfg Config) fx.Option {
+	options := []fx.Option{
+		fx.Provide(
+			func(lc fx.Lifecycle) (*otris.Handler, error) {
+				if cfg.Builder == nil && cfg.Handler != nil {
+					b, err := cfg.Handler.Builder()
+					if err != nil {
+						return nil, err
+					}
+					// The writer is opened by the config, e.g. the rotating file, so the module closes it.
+					return newOwnedHandler(lc, b), nil
+				}
+				b := cfg.Builder
+				if b == nil {
+					b = otris.NewHandlerBuilder()
+				}
+				return NewHandler(lc, b), nil
+			},
+			NewLogger,
+		),
+	}
+	for _, name := range cfg.Loggers {
+		options = append(options, fx.Provide(fx.Annotate(namedLogger(name), fx.ResultTags(`name:"`+name+`"`))))
+	}
+	if cfg.SetDefault {
+		options = append(options, fx.Invoke(slog.SetDefault))
+	}
+
+	module := fx.Module("otris", options...)
+	if cfg.NoEventLogger {
+		return module
+	}
+	return fx.Options(module, fx.WithLogger(func(logger *slog.Logger) fxevent.Logger {
+		return NewSlogLogger(logger)
+	}))
+}
+
+// NewLogger creates the *slog.Logger with the handler.
+func NewLogger(h *otris.Handler) *slog.Logger {
+	return slog.New(h)
+}
+
+// namedLogger returns the constructor of the child logger with the module name.
+func namedLogger(name string) func(logger *slog.Logger) *slog.Logger {
+	return func(logger *slog.Logger) *slog.Logger {
+		return logger.With(otris.ModuleKey, name)
+	}
+}
@@ -0,0 +1,158 @@
+//go:build go1.21
+
+package fx
+
+import (
+	"context"
+	"errors"
+	"github.com/Totus-Floreo/otris"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModuleLoggers(t *testing.T) {
+	w := new(syncRecorder)
+	app := fxtest.New(t,
+		fx.NopLogger,
+		Module(Config{Builder: newTestBuilder(w, slog.LevelInfo), Loggers: []string{"db", "http"}, NoEventLogger: true}),
+		fx.Invoke(func(p struct {
+			fx.In
+
+			Logger *slog.Logger
+			DB     *slog.Logger `name:"db"`
+			HTTP   *slog.Logger `name:"http"`
+		}) {
+			p.Logger.Info("started")
+			p.DB.Info("connected")
+			p.HTTP.Info("listening")
+		}),
+	)
+	app.RequireStart().RequireStop()
+
+	want := "level=INFO msg=started\n" +
+		"level=INFO msg=connected module=db\n" +
+		"level=INFO msg=listening module=http\n" +
+		"sync"
+	if got := strings.Join(w.Events(), "\n"); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestModuleEventLogger(t *testing.T) {
+	cases := []struct {
+		name          string
+		noEventLogger bool
+		want          bool
+	}{
+		{"event logger", false, true},
+		{"no event logger", true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := new(syncRecorder)
+			app := fxtest.New(t,
+				Module(Config{Builder: newTestBuilder(w, otris.LevelFx), NoEventLogger: c.noEventLogger}),
+				fx.Invoke(func(*slog.Logger) {}),
+			)
+			app.RequireStart().RequireStop()
+
+			events := w.Events()
+			got := slices.ContainsFunc(events, func(line string) bool { return strings.HasPrefix(line, "level=FX ") })
+			if got != c.want {
+				t.Errorf("\ngot  %t %q\nwant %t", got, events, c.want)
+			}
+		})
+	}
+}
+
+func TestModuleSetDefault(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	for _, setDefault := range []bool{false, true} {
+		w := new(syncRecorder)
+		var logger *slog.Logger
+		app := fxtest.New(t,
+			fx.NopLogger,
+			Module(Config{Builder: newTestBuilder(w, slog.LevelInfo), SetDefault: setDefault, NoEventLogger: true}),
+			fx.Populate(&logger),
+		)
+		app.RequireStart()
+		if got := slog.Default() == logger; got != setDefault {
+			t.Errorf("SetDefault: %t\ngot  %t\nwant %t", setDefault, got, setDefault)
+		}
+		app.RequireStop()
+		slog.SetDefault(defaultLogger)
+	}
+}
+
+func TestModuleHandlerConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	var logger *slog.Logger
+	app := fxtest.New(t,
+		fx.NopLogger,
+		Module(Config{Handler: &otris.Config{Format: otris.FormatJSON, Level: "warn", Output: name}, NoEventLogger: true}),
+		fx.Populate(&logger),
+	)
+	app.RequireStart()
+	logger.Info("hidden")
+	logger.Warn("disk", "free", 10)
+	app.RequireStop()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := string(data), `"level":"WARN","msg":"disk","free":10}`+"\n"
+	if strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, want) {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+
+	// The invalid config fails the application.
+	err = fx.New(fx.NopLogger, Module(Config{Handler: &otris.Config{Level: "loud"}}), fx.Invoke(func(*slog.Logger) {})).Err()
+	if err == nil {
+		t.Error("\ngot  nil\nwant error")
+	}
+}
+
+func TestModuleHandlerConfigClose(t *testing.T) {
+	before := otrisGoroutines()
+	var logger *slog.Logger
+	app := fxtest.New(t,
+		fx.NopLogger,
+		Module(Config{Handler: &otris.Config{Output: filepath.Join(t.TempDir(), "app.log"), Reopen: true}, NoEventLogger: true}),
+		fx.Populate(&logger),
+	)
+	app.RequireStart().RequireStop()
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "stopped", 0)
+	if err := logger.Handler().Handle(context.Background(), record); !errors.Is(err, otris.ErrFileClosed) {
+		t.Errorf("\ngot  %v\nwant %v", err, otris.ErrFileClosed)
+	}
+	for deadline := time.Now().Add(time.Second); otrisGoroutines() > before; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("\ngot  %d goroutines\nwant %d", otrisGoroutines(), before)
+		}
+	}
+}
+
+// otrisGoroutines returns the number of goroutines running the code of the otris package.
+func otrisGoroutines() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	n := 0
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "github.com/Totus-Floreo/otris.") {
+			n++
+		}
+	}
+	return n
+}
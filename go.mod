@@ -4,12 +4,13 @@ go 1.21.0
 
 require (
 	github.com/fatih/color v1.17.0
+	github.com/mattn/go-isatty v0.0.20
 	go.uber.org/fx v1.22.1
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
+	go.uber.org/dig v1.17.1 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	go.uber.org/zap v1.26.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
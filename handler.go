@@ -14,6 +14,7 @@ import (
 	"log/slog"
 	"slices"
 	"sync"
+	"time"
 )
 
 // Handler is a modified version of the original commonHandler from the log/slog.
@@ -21,23 +22,41 @@ type Handler struct {
 	// commonHandler fields
 	json              bool                 // Default for json is false
 	pretty            bool                 // Default for pretty is false
+	logfmt            bool                 // Default for logfmt is false
 	safe              bool                 // The `safe` field is a boolean flag that indicates whether the handler is in a safe set or not.
 	sep               string               // Default for sep is " "
 	layout            string               // Default for layout is otris.DefaultDateTimeLayout
 	color             LevelColorMap        // Color map for different log levels
-	opts              *slog.HandlerOptions // Warning! HandlerOptions is WIP in v2. You can use it, but at one's own risk.
+	shortLevels       bool                 // Default for shortLevels is false, used only in pretty mode
+	valueColor        ColorMapV2           // Color map for attribute values, used only in pretty mode
+	prettyKeys        bool                 // Default for prettyKeys is false, keys are rendered only in pretty mode
+	keyColor          LogColor             // Color of the keys in pretty mode
+	valueOnly         map[string]struct{}  // Keys that stay value-only when prettyKeys is true
+	columns           columnSet            // Columns of the tabular pretty layout, shared with clones
+	multiline         bool                 // Default for multiline is false, used only in pretty mode
+	maxInline         int                  // Maximum width of the inline strings in multi-line pretty mode, 0 means no limit
+	modules           *ModuleLevels        // Levels of the modules, they take precedence over opts.Level
+	module            string               // Module of the handler from WithGroup or the ModuleKey attribute
+	extractors        []ContextExtractor   // Extractors of the context attributes
+	trace             TraceProvider        // Provider of the trace and span IDs from the context
+	sampler           *sampler             // Sampler and rate limiter of the records, shared with clones
+	dedup             *dedupState          // State of the dedup mode, shared with clones and guarded by mu
+	repeatColor       LogColor             // Color of the repeat count of the dedup mode in pretty mode
+	colorMode         ColorMode            // ColorAlways or ColorNever, ColorAuto is resolved by the constructors
+	opts              *slog.HandlerOptions // ReplaceAttr is supported in every mode, the built-ins keep their otris formatting
 	preformattedAttrs []byte
+	preformattedTail  []byte
 	groupPrefix       string
 	groups            []string
 	nOpenGroups       int
 	buf               *bytes.Buffer
 	mu                *sync.Mutex
+	closed            *bool // the writer is closed by Close, shared with clones and guarded by mu
 	w                 io.Writer
 }
 
 // NewHandler is manually constructor, please use NewHandlerBuilder.
 // `safeSet` from coding/json/tables.go is used to escape the string
-// Warning! HandlerOptions is WIP. You can use it, but at one's own risk.
 // Only for tests, use builder please! Default setting close to NewPrettyHandler.
 func NewHandler(w io.Writer, color LevelColorMap, safe bool, layout string, sep string, opts *slog.HandlerOptions) *Handler {
 	if opts == nil {
@@ -53,15 +72,17 @@ func NewHandler(w io.Writer, color LevelColorMap, safe bool, layout string, sep
 		color = DefaultColorMap
 	}
 	return &Handler{
-		json:   false,
-		pretty: true,
-		safe:   safe,
-		color:  color,
-		layout: layout,
-		sep:    sep,
-		w:      w,
-		opts:   opts,
-		mu:     &sync.Mutex{},
+		json:      false,
+		pretty:    true,
+		safe:      safe,
+		color:     color,
+		layout:    layout,
+		sep:       sep,
+		colorMode: resolveColorMode(ColorAuto, w),
+		w:         w,
+		opts:      opts,
+		mu:        &sync.Mutex{},
+		closed:    new(bool),
 	}
 }
 
@@ -72,15 +93,17 @@ func NewPrettyHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
 		opts = &slog.HandlerOptions{}
 	}
 	return &Handler{
-		json:   false,
-		pretty: true,
-		safe:   false,
-		color:  DefaultColorMap,
-		layout: DefaultPrettyDateTimeLayout,
-		sep:    PrettySep,
-		w:      w,
-		opts:   opts,
-		mu:     &sync.Mutex{},
+		json:      false,
+		pretty:    true,
+		safe:      false,
+		color:     DefaultColorMap,
+		layout:    DefaultPrettyDateTimeLayout,
+		sep:       PrettySep,
+		colorMode: resolveColorMode(ColorAuto, w),
+		w:         w,
+		opts:      opts,
+		mu:        &sync.Mutex{},
+		closed:    new(bool),
 	}
 }
 
@@ -98,37 +121,142 @@ func NewJSONHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
 		w:      w,
 		opts:   opts,
 		mu:     &sync.Mutex{},
+		closed: new(bool),
 	}
 }
 
 // NewStructHandler is analog for slog.NewTextHandler
 func NewStructHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	return &Handler{
+		json:      false,
+		pretty:    false,
+		safe:      true,
+		sep:       StructSep,
+		colorMode: resolveColorMode(ColorAuto, w),
+		w:         w,
+		opts:      opts,
+		mu:        &sync.Mutex{},
+		closed:    new(bool),
+	}
+}
+
+// NewLogfmtHandler is a handler producing output that strictly conforms to logfmt.
+// Unlike NewStructHandler, the keys are sanitized and the values are quoted with the JSON escaping.
+func NewLogfmtHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
 	if opts == nil {
 		opts = &slog.HandlerOptions{}
 	}
 	return &Handler{
 		json:   false,
 		pretty: false,
+		logfmt: true,
 		safe:   true,
 		sep:    StructSep,
 		w:      w,
 		opts:   opts,
 		mu:     &sync.Mutex{},
+		closed: new(bool),
 	}
 }
 
+// Enabled reports whether the handler handles records at the given level.
+// If the handler has no module, the records may carry the ModuleKey attribute,
+// so the minimum level of all module rules is used and Handle checks the level of the record module.
 func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := h.level()
+	if h.modules != nil {
+		if h.module != "" {
+			if lvl, ok := h.modules.Level(h.module); ok {
+				minLevel = lvl
+			}
+		} else if lvl, ok := h.modules.minLevel(); ok && lvl < minLevel {
+			minLevel = lvl
+		}
+	}
+	return level >= minLevel
+}
+
+// level returns the minimum level from the options.
+func (h *Handler) level() slog.Level {
 	minLevel := LevelFx
 	if h.opts.Level != nil {
 		minLevel = h.opts.Level.Level()
 	}
-	return level >= minLevel
+	return minLevel
+}
+
+// enabledModule reports whether the record is enabled for the module of the ModuleKey attribute
+// of the record or of the context.
+func (h *Handler) enabledModule(record slog.Record, ctxAttrs []slog.Attr) bool {
+	if h.modules == nil || h.module != "" {
+		return true
+	}
+	module := recordModule(record)
+	for _, a := range ctxAttrs {
+		if m, ok := moduleOf(a); ok && module == "" {
+			module = m
+		}
+	}
+	minLevel, ok := h.modules.Level(module)
+	if !ok {
+		minLevel = h.level()
+	}
+	return record.Level >= minLevel
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	ctxAttrs := ContextAttrs(ctx)
+	if !h.allow(record, ctxAttrs) {
+		return nil
+	}
+	return h.handle(ctx, record, ctxAttrs)
 }
 
-func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+// allow reports whether the record passes the levels of the modules and the sampler.
+func (h *Handler) allow(record slog.Record, ctxAttrs []slog.Attr) bool {
+	if !h.enabledModule(record, ctxAttrs) {
+		return false
+	}
+	if h.sampler != nil {
+		now := record.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		return h.sampler.allow(record.Level, record.Message, now)
+	}
+	return true
+}
+
+// handle formats and writes the record, the record is not filtered by the modules and the sampler.
+func (h *Handler) handle(ctx context.Context, record slog.Record, ctxAttrs []slog.Attr) error {
 	// Use an empty separator for reuse later, since it is always inserted during state.append...
 	state := h.newHandleState(buffer.New(), true, "")
 	defer state.free()
+	keyStart := h.format(&state, ctx, record, ctxAttrs)
+	return h.write(*state.buf, keyStart, record)
+}
+
+// write writes the formatted record, in the dedup mode the repeated records are counted instead.
+func (h *Handler) write(line []byte, keyStart int, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.dedup != nil {
+		return h.writeDeduped(line, keyStart, record)
+	}
+	_, err := h.w.Write(line)
+	return err
+}
+
+// format appends the record to the buffer of the state.
+// It returns the offset of the record after the time, the rest is compared by the dedup mode.
+func (h *Handler) format(state *handleState, ctx context.Context, record slog.Record, ctxAttrs []slog.Attr) (keyStart int) {
+	state.builtin = true
+	if h.multiline {
+		state.tail = buffer.New()
+	}
 	if h.json {
 		state.buf.WriteByte('{')
 	}
@@ -145,42 +273,53 @@ func (h *Handler) Handle(_ context.Context, record slog.Record) error {
 			state.appendKey(key)
 			state.appendTime(val)
 		} else {
-			state.appendAttr(slog.Time(key, val)) // <- TODO Refactor state.appendAttr in v2
+			// The time keeps the time layout, since state.appendValue formats it with state.appendTime.
+			state.appendAttr(slog.Time(key, val))
 		}
 	}
+	keyStart = len(*state.buf)
 
 	// level
 	key := slog.LevelKey
 	val := record.Level
-	state.color = GetColor(h.color, val)
 	if rep == nil {
-		state.appendKey(key)
-		state.appendString(GetLevelName(val))
+		state.appendLevel(key, val)
 	} else {
-		state.appendAttr(slog.Any(key, val)) // <- TODO Refactor state.appendAttr in v2
+		// The level keeps its otris name and color unless ReplaceAttr changes the type of the value.
+		state.appendAttr(slog.Any(key, val))
 	}
-	state.resetColor()
 
 	// source
 	if h.opts.AddSource {
-		state.appendAttr(slog.Any(slog.SourceKey, rSource(record))) // <- TODO Refactor state.appendAttr in v2
+		state.appendAttr(slog.Any(slog.SourceKey, rSource(record)))
 	}
 	key = slog.MessageKey
-	msg := record.Message
-	if rep == nil {
+	msg := slog.StringValue(record.Message)
+	if state.repeated > 0 && h.pretty {
+		// The count of the summary keeps the repeat color unless ReplaceAttr changes the type of the value.
+		msg = slog.AnyValue(repeatedMessage(state.repeated))
+	}
+	if rep == nil && msg.Kind() == slog.KindString {
 		state.appendKey(key)
-		state.appendString(msg)
+		start := len(*state.buf)
+		state.appendString(msg.String())
+		state.alignColumn(key, start)
 	} else {
-		state.appendAttr(slog.String(key, msg)) // <- TODO Refactor state.appendAttr in v2
+		state.appendAttr(slog.Attr{Key: key, Value: msg})
 	}
+	state.builtin = false
+	// Context attributes. They are not in a group too.
+	state.appendTrace(ctx)
+	state.appendContextAttrs(ctx)
 	state.groups = stateGroups // Restore groups passed to ReplaceAttrs.
-	state.appendNonBuiltIns(record)
+	state.appendNonBuiltIns(record, ctxAttrs)
+	state.trimPadding()
 	state.buf.WriteByte('\n')
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	_, err := h.w.Write(*state.buf)
-	return err
+	if h.multiline {
+		state.buf.Write(h.preformattedTail)
+		state.buf.Write(*state.tail)
+	}
+	return keyStart
 }
 
 // WithAttrs returns a new Handler with additional attributes specified in `attrs` parameter.
@@ -196,6 +335,9 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	// Use an empty separator for reuse later, since it is always inserted during state.append...
 	state := h2.newHandleState((*buffer.Buffer)(&h2.preformattedAttrs), false, "")
 	defer state.free()
+	if h2.multiline {
+		state.tail = (*buffer.Buffer)(&h2.preformattedTail)
+	}
 	state.prefix.WriteString(h.groupPrefix)
 	if len(h2.preformattedAttrs) > 0 {
 		state.sep = h.attrSep()
@@ -209,6 +351,11 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	// Remember how many opened groups are in preformattedAttrs,
 	// so we don't open them again when we handle a Record.
 	h2.nOpenGroups = len(h2.groups)
+	for _, a := range attrs {
+		if module, ok := moduleOf(a); ok {
+			h2.module = module
+		}
+	}
 	return h2
 }
 
@@ -216,6 +363,7 @@ func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (h *Handler) WithGroup(name string) slog.Handler {
 	h2 := h.clone()
 	h2.groups = append(h2.groups, name)
+	h2.module = joinModule(h2.module, name)
 	return h2
 }
 
@@ -223,18 +371,37 @@ func (h *Handler) clone() *Handler {
 	return &Handler{
 		json:              h.json,
 		pretty:            h.pretty,
+		logfmt:            h.logfmt,
 		safe:              h.safe,
 		sep:               h.sep,
 		layout:            h.layout,
 		color:             h.color,
+		shortLevels:       h.shortLevels,
+		valueColor:        h.valueColor,
+		prettyKeys:        h.prettyKeys,
+		keyColor:          h.keyColor,
+		valueOnly:         h.valueOnly,
+		columns:           h.columns,
+		multiline:         h.multiline,
+		maxInline:         h.maxInline,
+		modules:           h.modules,
+		module:            h.module,
+		extractors:        h.extractors,
+		trace:             h.trace,
+		sampler:           h.sampler,
+		dedup:             h.dedup,
+		repeatColor:       h.repeatColor,
+		colorMode:         h.colorMode,
 		opts:              h.opts,
 		preformattedAttrs: slices.Clip(h.preformattedAttrs),
+		preformattedTail:  slices.Clip(h.preformattedTail),
 		groupPrefix:       h.groupPrefix,
 		groups:            slices.Clip(h.groups),
 		nOpenGroups:       h.nOpenGroups,
 		buf:               h.buf,
 		w:                 h.w,
 		mu:                h.mu,
+		closed:            h.closed,
 	}
 }
 
@@ -246,3 +413,16 @@ func (h *Handler) attrSep() string {
 	}
 	return h.sep
 }
+
+// isValueOnly reports whether the key stays value-only in pretty mode.
+// The key with the group prefix takes precedence over the bare key.
+func (h *Handler) isValueOnly(prefix, key string) bool {
+	if _, ok := h.valueOnly[key]; ok {
+		return true
+	}
+	if prefix != "" {
+		_, ok := h.valueOnly[prefix+key]
+		return ok
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"sync"
+	"time"
 )
 
 // HandlerBuilder is a type that helps in building a Handler by setting various options.
@@ -13,7 +14,11 @@ import (
 //
 //	handler := NewHandlerBuilder().WithColor(color).WithSafeSet(safe).WithTimeLayout(layout).Build()
 type HandlerBuilder struct {
-	h *Handler
+	h           *Handler
+	asyncSize   int
+	asyncPolicy OverflowPolicy
+	sampling    *SamplingOptions
+	autoFormat  *autoFormat
 }
 
 // NewHandlerBuilder creates a new instance of HandlerBuilder. It initializes the fields of HandlerBuilder
@@ -23,15 +28,18 @@ type HandlerBuilder struct {
 func NewHandlerBuilder() *HandlerBuilder {
 	return &HandlerBuilder{
 		h: &Handler{
-			json:   false,
-			pretty: false,
-			safe:   true,
-			color:  EmptyColorMap,
-			layout: DefaultDateTimeLayout,
-			sep:    StructSep,
-			w:      os.Stdout,
-			opts:   &slog.HandlerOptions{},
-			mu:     &sync.Mutex{},
+			json:        false,
+			pretty:      false,
+			safe:        true,
+			color:       EmptyColorMap,
+			keyColor:    DefaultKeyColor,
+			repeatColor: DefaultRepeatColor,
+			layout:      DefaultDateTimeLayout,
+			sep:         StructSep,
+			w:           os.Stdout,
+			opts:        &slog.HandlerOptions{},
+			mu:          &sync.Mutex{},
+			closed:      new(bool),
 		},
 	}
 }
@@ -39,6 +47,7 @@ func NewHandlerBuilder() *HandlerBuilder {
 // WithPretty sets the `pretty`, `safe`, `color`, `layout`, and `sep` fields of the HandlerBuilder to their pretty values.
 // It updates the pretty flag to true, the safe flag to true, the color map with the DefaultColorMap,
 // the layout to DefaultPrettyDateTimeLayout, and the sep to PrettySep.
+// The strings containing the separator or starting with a quote are quoted, so the Parser can read the line back.
 // Returns the updated HandlerBuilder.
 func (b *HandlerBuilder) WithPretty() *HandlerBuilder {
 	b.h.pretty = true
@@ -59,6 +68,66 @@ func (b *HandlerBuilder) WithColor(color LevelColorMap) *HandlerBuilder {
 	return b
 }
 
+// WithShortLevelNames sets the shortLevels flag to TRUE for the HandlerBuilder.
+// If the shortLevels flag is true, the levels are rendered with their short names in pretty mode, e.g. "DBG".
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithShortLevelNames() *HandlerBuilder {
+	b.h.shortLevels = true
+	return b
+}
+
+// WithValueColor sets the color map for attribute values in the HandlerBuilder, e.g. DefaultColorMapV2.
+// The value colors are applied only in pretty mode, they are disabled by default.
+// If the color map is not nil, it updates the value color map of the Handler.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithValueColor(color ColorMapV2) *HandlerBuilder {
+	if color != nil {
+		b.h.valueColor = color.index()
+	}
+	return b
+}
+
+// WithPrettyKeys enables rendering of attribute keys in pretty mode as `key=value`.
+// The keys listed in `valueOnly` stay value-only, they can be bare or prefixed with groups, e.g. "request.id".
+// Built-in attributes are always value-only. Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithPrettyKeys(valueOnly ...string) *HandlerBuilder {
+	b.h.prettyKeys = true
+	b.h.valueOnly = make(map[string]struct{}, len(valueOnly))
+	for _, key := range valueOnly {
+		b.h.valueOnly[key] = struct{}{}
+	}
+	return b
+}
+
+// WithKeyColor sets the color of the attribute keys rendered by WithPrettyKeys in the HandlerBuilder.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithKeyColor(color LogColor) *HandlerBuilder {
+	b.h.keyColor = color
+	return b
+}
+
+// WithColumns enables the tabular pretty layout in the HandlerBuilder.
+// The values of the given columns are padded to fixed or learned widths and truncated with an ellipsis
+// if they are longer than the maximum width. If no columns are given, DefaultColumns is used.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithColumns(cols ...Column) *HandlerBuilder {
+	if len(cols) == 0 {
+		cols = DefaultColumns
+	}
+	b.h.columns = newColumnSet(cols)
+	return b
+}
+
+// WithMultiline enables the multi-line pretty layout in the HandlerBuilder.
+// The main line is followed by the indented tree of nested groups, multi-line strings
+// and strings wider than `maxInline`, if `maxInline` is positive.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithMultiline(maxInline int) *HandlerBuilder {
+	b.h.multiline = true
+	b.h.maxInline = maxInline
+	return b
+}
+
 // WithInsecure sets the safe flag to FALSE for the HandlerBuilder.
 // If the insecure flag is true, it indicates that the handler is in a safe set.
 // Returns the updated HandlerBuilder.
@@ -97,6 +166,41 @@ func (b *HandlerBuilder) WithOptions(opts *slog.HandlerOptions) *HandlerBuilder
 	return b
 }
 
+// WithModuleLevels sets the levels of the modules for the HandlerBuilder.
+// The level of a module takes precedence over the level from the options,
+// which is still used for the modules without rules.
+// The levels can be changed at runtime through ModuleLevels without rebuilding the handler.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithModuleLevels(modules *ModuleLevels) *HandlerBuilder {
+	b.h.modules = modules
+	return b
+}
+
+// WithContextExtractor adds the context extractors to the HandlerBuilder.
+// The attributes pulled out of the context by the extractors are appended to every record
+// after the built-in attributes, they can be aligned with WithColumns in pretty mode.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithContextExtractor(extractors ...ContextExtractor) *HandlerBuilder {
+	for _, extract := range extractors {
+		if extract != nil {
+			b.h.extractors = append(b.h.extractors, extract)
+		}
+	}
+	return b
+}
+
+// WithTrace enables the trace correlation in the HandlerBuilder.
+// The trace and span IDs from the provider are appended as `trace_id` and `span_id`,
+// or as a short colored tag in pretty mode. If the provider is nil, TraceparentProvider is used.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithTrace(provider TraceProvider) *HandlerBuilder {
+	if provider == nil {
+		provider = TraceparentProvider
+	}
+	b.h.trace = provider
+	return b
+}
+
 // WithWriter sets the writer for the HandlerBuilder.
 // If the writer is not nil, it updates the writer of the Handler.
 // Returns the updated HandlerBuilder.
@@ -107,6 +211,73 @@ func (b *HandlerBuilder) WithWriter(w io.Writer) *HandlerBuilder {
 	return b
 }
 
+// WithDedup enables the dedup mode in the HandlerBuilder. The identical consecutive records,
+// with the same level, message and attributes, are suppressed within the window, DefaultDedupWindow
+// if window is not positive. They are summarized by a single "last message repeated N times" record
+// when a different record is logged, the window is over, even if nothing is logged after, or the Handler is flushed.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithDedup(window time.Duration) *HandlerBuilder {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	b.h.dedup = &dedupState{window: window}
+	return b
+}
+
+// WithRepeatColor sets the color of the repeat count of the dedup mode in pretty mode in the HandlerBuilder.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithRepeatColor(color LogColor) *HandlerBuilder {
+	b.h.repeatColor = color
+	return b
+}
+
+// WithSampling enables the sampling and the rate limiting of the records in the HandlerBuilder.
+// The records dropped by the Handler and its clones are counted and logged in a warning record
+// opts.SummaryInterval after the first of them and on Flush, Sync and Close, see SamplingOptions.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithSampling(opts SamplingOptions) *HandlerBuilder {
+	b.sampling = &opts
+	return b
+}
+
+// WithAsync enables the async mode in the HandlerBuilder.
+// The records are buffered in a ring buffer of size records, DefaultAsyncSize if size is not positive,
+// and written by a background goroutine, the policy defines what happens when the buffer is full.
+// The number of the dropped records is logged as a warning record every DefaultDropReportInterval.
+// The Handler must be closed to write the buffered records and stop the goroutine.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithAsync(size int, policy OverflowPolicy) *HandlerBuilder {
+	if size <= 0 {
+		size = DefaultAsyncSize
+	}
+	b.asyncSize = size
+	b.asyncPolicy = policy
+	return b
+}
+
+// WithColorMode sets the color mode for the HandlerBuilder. The colors of the Handler are independent
+// of the other handlers and of the global color.NoColor, ColorAuto is resolved by the writer in Build.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithColorMode(mode ColorMode) *HandlerBuilder {
+	b.h.colorMode = mode
+	return b
+}
+
+// WithAutoFormat selects the format by the writer in Build, so it doesn't depend on the order of WithWriter.
+// If the writer is a terminal, the pretty format is selected, otherwise the fallback format,
+// FormatJSON, FormatLogfmt or FormatStruct. The colors are enabled only on the terminals,
+// NO_COLOR disables them and TERM=dumb disables them on the terminals. FORCE_COLOR enables them
+// and selects the pretty format even if the writer is not a terminal, FORCE_COLOR=0 disables them.
+// The options set after WithAutoFormat override the defaults of the selected format.
+// Returns the updated HandlerBuilder.
+func (b *HandlerBuilder) WithAutoFormat(fallback Format) *HandlerBuilder {
+	b.autoFormat = &autoFormat{fallback: fallback, color: b.h.color, layout: b.h.layout, sep: b.h.sep}
+	// The options set after are kept by Build.
+	b.h.color, b.h.layout, b.h.sep = nil, "", ""
+	b.h.colorMode = ColorAuto
+	return b
+}
+
 // WithJSON sets the JSON flag to TRUE for the HandlerBuilder.
 // If the JSON flag is true, it indicates that the log messages should be formatted in JSON.
 // Returns the updated HandlerBuilder.
@@ -117,20 +288,59 @@ func (b *HandlerBuilder) WithJSON() *HandlerBuilder {
 	return b
 }
 
+// WithLogfmt sets the logfmt flag to TRUE for the HandlerBuilder.
+// If the logfmt flag is true, it indicates that the log messages should be formatted in strict logfmt,
+// that is guaranteed to round-trip through a logfmt parser.
+// Returns the updated HandlerBuilder.
+//
+// !!!Warning!!! Logfmt mode disable all addition otris functions. !!!Warning!!!
+func (b *HandlerBuilder) WithLogfmt() *HandlerBuilder {
+	b.h.logfmt = true
+	return b
+}
+
 // Build returns the final built Handler instance from the HandlerBuilder.
 // It simply returns the value of the h field in the HandlerBuilder.
 // If pretty is true, then insecure is enabled.
-// If json is true, then pretty, insecure, color is disabled and sep is ','.
+// If json is true, then pretty, logfmt, insecure, color, valueColor, columns, multiline is disabled and sep is ','.
+// If logfmt is true, then pretty, insecure, color, valueColor, columns, multiline is disabled and sep is ' '.
+// The ColorAuto color mode is resolved by the writer. If sampling is enabled, the sampler is created. If async is enabled, the writer is wrapped in an AsyncWriter.
 // Returns the final built Handler instance.
 func (b *HandlerBuilder) Build() *Handler {
+	if b.autoFormat != nil {
+		b.autoFormat.apply(b)
+		b.autoFormat = nil
+	}
 	if b.h.json {
 		b.h.pretty = false
 		b.h.safe = true
 		b.h.sep = JSONSep
 		b.h.color = EmptyColorMap
+		b.h.valueColor = nil
+		b.h.columns = nil
+		b.h.multiline = false
+		b.h.logfmt = false
+	}
+	if b.h.logfmt {
+		b.h.pretty = false
+		b.h.safe = true
+		b.h.sep = StructSep
+		b.h.color = EmptyColorMap
+		b.h.valueColor = nil
+		b.h.columns = nil
+		b.h.multiline = false
 	}
 	if b.h.pretty {
 		b.h.safe = false
 	}
+	b.h.colorMode = resolveColorMode(b.h.colorMode, b.h.w)
+	if b.sampling != nil && b.h.sampler == nil {
+		b.h.sampler = newSampler(*b.sampling, b.h.reportSampled)
+	}
+	if b.asyncSize > 0 {
+		if _, ok := b.h.w.(*AsyncWriter); !ok {
+			b.h.w = newAsyncWriter(b.h.w, b.asyncSize, b.asyncPolicy, b.h.reportDropped, DefaultDropReportInterval)
+		}
+	}
 	return b.h
 }
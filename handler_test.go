@@ -5,6 +5,8 @@ import (
 	"context"
 	"github.com/fatih/color"
 	"log/slog"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -345,3 +347,602 @@ func TestNewPrettyHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestColorMapV2(t *testing.T) {
+	colorMap := ColorMapV2{
+		"httpcode": HTTPCodeColors,
+		"sqlstate": SQLStateColors,
+		"service": {
+			Values: map[LogValue]LogColor{"PaymentService": LogColor(color.FgMagenta)},
+		},
+		"code": {
+			Values: map[LogValue]LogColor{200: LogColor(color.FgGreen), time.Second: LogColor(color.FgBlue)},
+		},
+		"deadline": {
+			Values: map[LogValue]LogColor{time.Unix(0, 0): LogColor(color.FgRed)},
+		},
+		"latency": {
+			Funcs: []ColorFunc{{
+				Match: func(v slog.Value) bool { return v.Kind() == slog.KindDuration && v.Duration() > time.Second },
+				Color: LogColor(color.FgRed),
+			}},
+		},
+	}
+
+	// Test cases
+	cases := []struct {
+		name  string
+		attr  slog.Attr
+		color LogColor
+		ok    bool
+	}{
+		{name: "2xx", attr: slog.Int("httpcode", 204), color: LogColor(color.FgGreen), ok: true},
+		{name: "4xx", attr: slog.Int("httpcode", 404), color: LogColor(color.FgYellow), ok: true},
+		{name: "5xx", attr: slog.Uint64("httpcode", 503), color: LogColor(color.FgRed), ok: true},
+		{name: "Out of range", attr: slog.Int("httpcode", 42), ok: false},
+		{name: "SQL success", attr: slog.String("sqlstate", "00000"), color: LogColor(color.FgGreen), ok: true},
+		{name: "SQL error", attr: slog.String("sqlstate", "23505"), color: LogColor(color.FgRed), ok: true},
+		{name: "Exact value", attr: slog.String("service", "PaymentService"), color: LogColor(color.FgMagenta), ok: true},
+		{name: "Predicate", attr: slog.Duration("latency", 2*time.Second), color: LogColor(color.FgRed), ok: true},
+		{name: "Predicate miss", attr: slog.Duration("latency", time.Millisecond), ok: false},
+		{name: "Unknown key", attr: slog.Int("status", 200), ok: false},
+		{name: "Int value", attr: slog.Int64("code", 200), color: LogColor(color.FgGreen), ok: true},
+		{name: "Duration value", attr: slog.Duration("code", time.Second), color: LogColor(color.FgBlue), ok: true},
+		{name: "Other kind", attr: slog.String("code", "200"), ok: false},
+		{name: "Time value", attr: slog.Time("deadline", time.Unix(0, 0)), color: LogColor(color.FgRed), ok: true},
+	}
+
+	// The indexed map looks the values up instead of comparing them.
+	for _, m := range []ColorMapV2{colorMap, colorMap.index()} {
+		for _, test := range cases {
+			t.Run(test.name, func(t *testing.T) {
+				got, ok := m.Color(test.attr.Key, test.attr.Value)
+				if ok != test.ok || got != test.color {
+					t.Errorf("\ngot  %d %t\nwant %d %t", got, ok, test.color, test.ok)
+				}
+			})
+		}
+	}
+}
+
+func TestHandlerBuilderWithValueColor(t *testing.T) {
+	var got bytes.Buffer
+	h := NewHandlerBuilder().WithPretty().WithWriter(&got).WithValueColor(DefaultColorMapV2).WithColorMode(ColorAlways).Build()
+
+	r := slog.NewRecord(time.Now(), LevelInfo, "message", 0)
+	r.AddAttrs(slog.Int("httpcode", 200), slog.Int("other", 500))
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "\x1b[32m200\x1b[0m"; !bytes.Contains(got.Bytes(), []byte(want)) {
+		t.Errorf("\ngot  %q\nwant %q inside", got.String(), want)
+	}
+	if want := " | 500\n"; !bytes.HasSuffix(got.Bytes(), []byte(want)) {
+		t.Errorf("\ngot  %q\nwant %q suffix", got.String(), want)
+	}
+
+	// The value colors are opt-in.
+	got.Reset()
+	h = NewHandlerBuilder().WithPretty().WithWriter(&got).WithColorMode(ColorAlways).Build()
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if want := " | 200 | 500\n"; !bytes.HasSuffix(got.Bytes(), []byte(want)) {
+		t.Errorf("\ngot  %q\nwant %q suffix", got.String(), want)
+	}
+}
+
+func TestHandlerBuilderWithPrettyKeys(t *testing.T) {
+	ctx := context.Background()
+
+	// Test cases
+	cases := []struct {
+		name      string
+		valueOnly []string
+		group     string
+		want      string
+	}{
+		{
+			name: "Case 1",
+			want: "INFO | message | pre=0 | a=1 | b=two\n",
+		},
+		{
+			name:      "Case 2",
+			valueOnly: []string{"b"},
+			want:      "INFO | message | pre=0 | a=1 | two\n",
+		},
+		{
+			name:      "Case 3",
+			valueOnly: []string{"request.a"},
+			group:     "request",
+			want:      "INFO | message | pre=0 | 1 | request.b=two\n",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var got bytes.Buffer
+			var h slog.Handler = NewHandlerBuilder().WithPretty().WithWriter(&got).WithPrettyKeys(test.valueOnly...).Build()
+			h = h.WithAttrs([]slog.Attr{slog.Int("pre", 0)})
+			if test.group != "" {
+				h = h.WithGroup(test.group)
+			}
+
+			r := slog.NewRecord(time.Time{}, LevelInfo, "message", 0)
+			r.AddAttrs(slog.Int("a", 1), slog.String("b", "two"))
+			if err := h.Handle(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestHandlerBuilderWithColumns(t *testing.T) {
+	ctx := context.Background()
+
+	var got bytes.Buffer
+	h := NewHandlerBuilder().WithPretty().WithWriter(&got).WithColumns(
+		Column{Key: slog.LevelKey},
+		Column{Key: slog.MessageKey, Max: 10},
+		Column{Key: "service", Width: 6},
+	).Build()
+
+	records := []struct {
+		level   slog.Level
+		msg     string
+		service string
+	}{
+		{level: LevelInfo, msg: "started", service: "db"},
+		{level: LevelWarning, msg: "high memory usage", service: "cache"},
+		{level: LevelError, msg: "接続エラー", service: "http"},
+	}
+	for _, rec := range records {
+		r := slog.NewRecord(time.Time{}, rec.level, rec.msg, 0)
+		r.AddAttrs(slog.String("service", rec.service), slog.Int("n", 1))
+		if err := h.Handle(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "INFO | started | db     | 1\n" +
+		"WARN | high memo… | cache  | 1\n" +
+		"ERROR | 接続エラー | http   | 1\n"
+	if got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	// Test cases
+	cases := []struct {
+		name      string
+		value     string
+		width     int
+		truncated string
+	}{
+		{name: "ASCII", value: "message", width: 7, truncated: "mess…"},
+		{name: "ANSI", value: "\x1b[32mmessage\x1b[0m", width: 7, truncated: "\x1b[32mmess…\x1b[0m"},
+		{name: "Wide", value: "接続エラー", width: 10, truncated: "接続…"},
+		{name: "Combining", value: "été", width: 3, truncated: "été"},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			if got := displayWidth([]byte(test.value)); got != test.width {
+				t.Errorf("\ngot  %d\nwant %d", got, test.width)
+			}
+			if got := string(truncateWidth([]byte(test.value), 5)); test.width > 5 && got != test.truncated {
+				t.Errorf("\ngot  %q\nwant %q", got, test.truncated)
+			}
+		})
+	}
+}
+
+func TestHandlerBuilderWithMultiline(t *testing.T) {
+	var got bytes.Buffer
+	var h slog.Handler = NewHandlerBuilder().WithPretty().WithWriter(&got).WithMultiline(0).Build()
+	h = h.WithAttrs([]slog.Attr{slog.Group("moduletrace", slog.String("0", "main.go:12"))})
+
+	r := slog.NewRecord(time.Time{}, LevelFxError, "invoke failed", 0)
+	r.AddAttrs(
+		slog.String("stack", "main.run\n\tmain.go:20\n"),
+		slog.Group("stacktrace", slog.String("0", "fx.New"), slog.Group("1", slog.Int("line", 7))),
+		slog.String("module", "Cache"),
+	)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "FXError | invoke failed | Cache\n" +
+		"    moduletrace:\n" +
+		"        0: main.go:12\n" +
+		"    stack:\n" +
+		"        main.run\n" +
+		"        \tmain.go:20\n" +
+		"    stacktrace:\n" +
+		"        0: fx.New\n" +
+		"        1:\n" +
+		"            line: 7\n"
+	if got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+
+	// The built-ins stay on the main line with ReplaceAttr.
+	got.Reset()
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+	h = NewHandlerBuilder().WithPretty().WithWriter(&got).WithOptions(opts).WithMultiline(0).Build()
+	slog.New(h).Info("first\nsecond", "stack", "a\nb")
+
+	want = "INFO | first\nsecond\n" +
+		"    stack:\n" +
+		"        a\n" +
+		"        b\n"
+	if got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+}
+
+func TestHandlerReplaceAttr(t *testing.T) {
+	ctx := context.Background()
+
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		switch {
+		case len(groups) == 0 && a.Key == slog.TimeKey:
+			return slog.Attr{}
+		case len(groups) == 0 && a.Key == slog.MessageKey:
+			a.Key = "message"
+		case a.Key == "password":
+			return slog.String(a.Key, "***")
+		case a.Key == "drop":
+			return slog.Attr{}
+		}
+		return a
+	}
+	opts := &slog.HandlerOptions{Level: LevelFx, ReplaceAttr: replace}
+
+	// Test cases
+	cases := []struct {
+		name string
+		h1   func(w *bytes.Buffer) slog.Handler
+		h2   func(w *bytes.Buffer) slog.Handler
+		want string
+	}{
+		{
+			name: "Struct",
+			h1:   func(w *bytes.Buffer) slog.Handler { return NewStructHandler(w, opts) },
+			h2:   func(w *bytes.Buffer) slog.Handler { return slog.NewTextHandler(w, opts) },
+		},
+		{
+			name: "JSON",
+			h1:   func(w *bytes.Buffer) slog.Handler { return NewJSONHandler(w, opts) },
+			h2:   func(w *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(w, opts) },
+		},
+		{
+			name: "Pretty",
+			h1:   func(w *bytes.Buffer) slog.Handler { return NewPrettyHandler(w, opts) },
+			want: "WARN | message | *** | ok | 1\n",
+		},
+		{
+			name: "Pretty with keys",
+			h1: func(w *bytes.Buffer) slog.Handler {
+				return NewHandlerBuilder().WithPretty().WithPrettyKeys().WithOptions(opts).WithWriter(w).Build()
+			},
+			want: "WARN | message | password=*** | group.user=ok | group.n=1\n",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var got bytes.Buffer
+			h := test.h1(&got).WithAttrs([]slog.Attr{slog.String("password", "secret")})
+
+			r := slog.NewRecord(time.Now(), LevelWarning, "message", 0)
+			r.AddAttrs(slog.Group("group", slog.String("user", "ok"), slog.Int("drop", 0), slog.Int("n", 1)))
+			if err := h.Handle(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+
+			want := test.want
+			if test.h2 != nil {
+				var buf bytes.Buffer
+				h2 := test.h2(&buf).WithAttrs([]slog.Attr{slog.String("password", "secret")})
+				if err := h2.Handle(ctx, r); err != nil {
+					t.Fatal(err)
+				}
+				want = buf.String()
+			}
+			if got.String() != want {
+				t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestHandlerReplaceAttrLevel(t *testing.T) {
+	var got bytes.Buffer
+	opts := &slog.HandlerOptions{
+		Level: LevelFx,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey {
+				a.Key = "severity"
+			}
+			return a
+		},
+	}
+	h := NewJSONHandler(&got, opts)
+
+	r := slog.NewRecord(time.Time{}, LevelFxError, "message", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"severity\":\"FXError\",\"msg\":\"message\"}\n"; got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+}
+
+func TestHandlerBuilderWithModuleLevels(t *testing.T) {
+	modules, err := NewModuleLevels("db=debug, http=warn, *=info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*=INFO,db=DEBUG,http=WARN"; modules.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", modules.String(), want)
+	}
+
+	var got bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+	logger := slog.New(NewHandlerBuilder().WithPretty().WithOptions(opts).WithWriter(&got).WithModuleLevels(modules).Build())
+	db := logger.WithGroup("db").WithGroup("sql")
+	http := logger.With(ModuleKey, "http")
+
+	db.Debug("query")
+	http.Info("request")
+	http.Warn("slow request")
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.Debug("provided", ModuleKey, "db")
+	logger.Log(context.Background(), LevelFx, "provided", ModuleKey, "db")
+
+	modules.SetLevel("http", LevelDebug)
+	http.Info("request")
+
+	want := "DEBUG | query\n" +
+		"WARN | slow request | http\n" +
+		"INFO | root info\n" +
+		"DEBUG | provided | db\n" +
+		"INFO | request | http\n"
+	if got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+}
+
+// removeTime is a ReplaceAttr function that removes the time, so the output can be compared.
+func removeTime(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+type requestIDKey struct{}
+
+func TestHandlerBuilderWithContextExtractor(t *testing.T) {
+	tenant := func(ctx context.Context) []slog.Attr {
+		return []slog.Attr{slog.String("tenant", "acme")}
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r-42")
+
+	var got bytes.Buffer
+	h := NewHandlerBuilder().WithJSON().WithWriter(&got).WithContextExtractor(ContextValue(requestIDKey{}, "request_id"), tenant).Build()
+	logger := slog.New(h).With("pre", 0).WithGroup("g")
+
+	logger.InfoContext(ctx, "message", "a", 1)
+	logger.InfoContext(context.Background(), "message")
+
+	want := `{"level":"INFO","msg":"message","request_id":"r-42","tenant":"acme","pre":0,"g":{"a":1}}` + "\n" +
+		`{"level":"INFO","msg":"message","tenant":"acme","pre":0}` + "\n"
+	if got := removeJSONTime(got.String()); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+// removeJSONTime removes the time from the JSON lines, so the output can be compared.
+func removeJSONTime(s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if start := strings.Index(line, `"time":`); start >= 0 {
+			end := strings.Index(line[start:], `",`)
+			lines[i] = line[:start] + line[start+end+2:]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func TestWithContextAttrs(t *testing.T) {
+	ctx := WithContextAttrs(context.Background(), slog.String("request_id", "r-42"))
+	ctx = WithContextAttrs(ctx, slog.String("user", "bob"))
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+
+	// Test cases
+	cases := []struct {
+		name    string
+		handler func(w *bytes.Buffer) slog.Handler
+		want    string
+	}{
+		{
+			name:    "Pretty",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewPrettyHandler(w, opts) },
+			want:    "INFO | message | 0 | r-42 | bob | 1\n",
+		},
+		{
+			name:    "Struct",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewStructHandler(w, opts) },
+			want:    "level=INFO msg=message pre=0 g.request_id=r-42 g.user=bob g.a=1\n",
+		},
+		{
+			name:    "JSON",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewJSONHandler(w, opts) },
+			want:    `{"level":"INFO","msg":"message","pre":0,"g":{"request_id":"r-42","user":"bob","a":1}}` + "\n",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var got bytes.Buffer
+			logger := slog.New(test.handler(&got)).With("pre", 0).WithGroup("g")
+			ctx := NewContext(ctx, logger)
+
+			FromContext(ctx).InfoContext(ctx, "message", "a", 1)
+			if got.String() != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got.String(), test.want)
+			}
+		})
+	}
+
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("FromContext doesn't return slog.Default for the context without a logger")
+	}
+}
+
+func TestHandlerBuilderWithTrace(t *testing.T) {
+	ctx, err := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+
+	// Test cases
+	cases := []struct {
+		name    string
+		builder func(b *HandlerBuilder) *HandlerBuilder
+		want    string
+	}{
+		{
+			name:    "Pretty",
+			builder: func(b *HandlerBuilder) *HandlerBuilder { return b.WithPretty() },
+			want:    "INFO | message | 4bf92f35:00f067aa | 1\n",
+		},
+		{
+			name:    "Struct",
+			builder: func(b *HandlerBuilder) *HandlerBuilder { return b },
+			want:    "level=INFO msg=message trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 g.a=1\n",
+		},
+		{
+			name:    "JSON",
+			builder: func(b *HandlerBuilder) *HandlerBuilder { return b.WithJSON() },
+			want:    `{"level":"INFO","msg":"message","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","g":{"a":1}}` + "\n",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var got bytes.Buffer
+			h := test.builder(NewHandlerBuilder().WithWriter(&got).WithOptions(opts).WithTrace(nil)).Build()
+			logger := slog.New(h).WithGroup("g")
+
+			logger.InfoContext(ctx, "message", "a", 1)
+			if got.String() != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-00",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(header); err == nil {
+			t.Errorf("ParseTraceparent(%q) doesn't return an error", header)
+		}
+	}
+
+	header := "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"
+	tp, err := ParseTraceparent(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; tp.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", tp.String(), want)
+	}
+}
+
+func TestHandlerBuilderWithAutoFormat(t *testing.T) {
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+
+	// Test cases
+	cases := []struct {
+		name     string
+		env      map[string]string
+		fallback Format
+		sep      string
+		want     string
+	}{
+		{
+			name:     "Pipe",
+			fallback: FormatJSON,
+			want:     `{"level":"INFO","msg":"message","a":1}` + "\n",
+		},
+		{
+			name:     "PipeStruct",
+			fallback: FormatStruct,
+			want:     "level=INFO msg=message a=1\n",
+		},
+		{
+			name:     "ForceColor",
+			env:      map[string]string{"FORCE_COLOR": "1"},
+			fallback: FormatJSON,
+			want:     "\x1b[92mINFO\x1b[0m | message | 1\n",
+		},
+		{
+			name:     "ForceColorSeparator",
+			env:      map[string]string{"FORCE_COLOR": "1"},
+			fallback: FormatJSON,
+			sep:      " ~ ",
+			want:     "\x1b[92mINFO\x1b[0m ~ message ~ 1\n",
+		},
+		{
+			name:     "PipeStructSeparator",
+			fallback: FormatStruct,
+			sep:      ", ",
+			want:     "level=INFO, msg=message, a=1\n",
+		},
+		{
+			name:     "NoColor",
+			env:      map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"},
+			fallback: FormatJSON,
+			want:     `{"level":"INFO","msg":"message","a":1}` + "\n",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "FORCE_COLOR", "TERM"} {
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			var got bytes.Buffer
+			// The format is selected by the writer set after WithAutoFormat.
+			h := NewHandlerBuilder().WithAutoFormat(test.fallback).WithWriter(&got).WithSeparator(test.sep).WithOptions(opts).Build()
+			slog.New(h).Info("message", "a", 1)
+			if got.String() != test.want {
+				t.Errorf("\ngot  %q\nwant %q", got.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package otris
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// LevelsHandler is an http.Handler to view and change the levels of the handlers at runtime.
+// GET returns the current levels, PUT replaces them and POST merges them with the current ones.
+// The levels are encoded with GetLevelName and decoded with ParseLevel.
+//
+// The body of the requests and responses is a JSON object:
+//
+//	{"level": "INFO", "modules": {"*": "INFO", "db": "DEBUG"}}
+//
+// A module with the null level is removed. Without a body, the `level` query parameter
+// and the `modules` query parameter with the rule set like "db=debug,*=info" are used.
+//
+// Usage:
+//
+//	level := new(slog.LevelVar)
+//	handler := NewHandlerBuilder().WithOptions(&slog.HandlerOptions{Level: level}).Build()
+//	mux.Handle("/log/level", NewLevelsHandler(level, nil))
+type LevelsHandler struct {
+	level   *slog.LevelVar
+	modules *ModuleLevels
+}
+
+// NewLevelsHandler creates a new LevelsHandler for the global level and the module levels, both can be nil.
+func NewLevelsHandler(level *slog.LevelVar, modules *ModuleLevels) *LevelsHandler {
+	return &LevelsHandler{level: level, modules: modules}
+}
+
+// levelsRequest is the body of the PUT and POST requests.
+type levelsRequest struct {
+	Level   *string            `json:"level"`
+	Modules map[string]*string `json:"modules"`
+}
+
+// levelsResponse is the body of the responses.
+type levelsResponse struct {
+	Level   string            `json:"level,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// maxLevelsRequestSize is the maximum size of the body of the requests.
+const maxLevelsRequestSize = 64 << 10
+
+func (h *LevelsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		req, err := decodeLevelsRequest(r)
+		if err == nil {
+			err = h.apply(req, r.Method == http.MethodPut)
+		}
+		if err != nil {
+			writeLevelsResponse(w, http.StatusBadRequest, levelsResponse{Error: err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		writeLevelsResponse(w, http.StatusMethodNotAllowed, levelsResponse{Error: fmt.Sprintf("method %s is not allowed", r.Method)})
+		return
+	}
+	writeLevelsResponse(w, http.StatusOK, h.levels())
+}
+
+// levels returns the current levels.
+func (h *LevelsHandler) levels() levelsResponse {
+	var resp levelsResponse
+	if h.level != nil {
+		resp.Level = GetLevelName(h.level.Level())
+	}
+	if h.modules != nil {
+		resp.Modules = make(map[string]string)
+		for module, lvl := range h.modules.Rules() {
+			resp.Modules[module] = GetLevelName(lvl)
+		}
+	}
+	return resp
+}
+
+// apply validates all levels of the request before changing any of them.
+// If replace is true, the module rules which are not in the request are removed.
+func (h *LevelsHandler) apply(req levelsRequest, replace bool) error {
+	var level slog.Level
+	if req.Level != nil {
+		if h.level == nil {
+			return errors.New("the global level can't be changed")
+		}
+		lvl, err := ParseLevel(*req.Level)
+		if err != nil {
+			return err
+		}
+		level = lvl
+	}
+	modules := make(map[string]slog.Level, len(req.Modules))
+	if req.Modules != nil {
+		if h.modules == nil {
+			return errors.New("the module levels can't be changed")
+		}
+		for module, name := range req.Modules {
+			if module == "" {
+				return errors.New("empty module")
+			}
+			if name == nil {
+				continue
+			}
+			lvl, err := ParseLevel(*name)
+			if err != nil {
+				return err
+			}
+			modules[module] = lvl
+		}
+	}
+
+	if req.Level != nil {
+		h.level.Set(level)
+	}
+	if req.Modules != nil {
+		if replace {
+			for module := range h.modules.Rules() {
+				if _, ok := modules[module]; !ok {
+					h.modules.Remove(module)
+				}
+			}
+		}
+		for module, name := range req.Modules {
+			if name == nil {
+				h.modules.Remove(module)
+			} else {
+				h.modules.SetLevel(module, modules[module])
+			}
+		}
+	}
+	return nil
+}
+
+// decodeLevelsRequest decodes the JSON body or, if the body is empty, the query parameters.
+func decodeLevelsRequest(r *http.Request) (levelsRequest, error) {
+	var req levelsRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLevelsRequestSize))
+	if err != nil {
+		return req, err
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, fmt.Errorf("invalid body: %w", err)
+		}
+		return req, nil
+	}
+	q := r.URL.Query()
+	if q.Has("level") {
+		level := q.Get("level")
+		req.Level = &level
+	}
+	if q.Has("modules") {
+		rules, err := ParseModuleLevels(q.Get("modules"))
+		if err != nil {
+			return req, err
+		}
+		req.Modules = make(map[string]*string, len(rules))
+		for module, lvl := range rules {
+			name := GetLevelName(lvl)
+			req.Modules[module] = &name
+		}
+	}
+	if req.Level == nil && req.Modules == nil {
+		return req, errors.New("no levels in the request")
+	}
+	return req, nil
+}
+
+func writeLevelsResponse(w http.ResponseWriter, code int, resp levelsResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
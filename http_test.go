@@ -0,0 +1,99 @@
+package otris
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelsHandler(t *testing.T) {
+	level := new(slog.LevelVar)
+	modules, err := NewModuleLevels("db=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewLevelsHandler(level, modules))
+	defer srv.Close()
+
+	// Test cases
+	cases := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		code   int
+		want   string
+	}{
+		{
+			name:   "Get",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			want:   `{"level":"INFO","modules":{"db":"DEBUG"}}`,
+		},
+		{
+			name:   "Post",
+			method: http.MethodPost,
+			body:   `{"level":"fx","modules":{"http":"warn"}}`,
+			code:   http.StatusOK,
+			want:   `{"level":"FX","modules":{"db":"DEBUG","http":"WARN"}}`,
+		},
+		{
+			name:   "Post remove",
+			method: http.MethodPost,
+			body:   `{"modules":{"db":null}}`,
+			code:   http.StatusOK,
+			want:   `{"level":"FX","modules":{"http":"WARN"}}`,
+		},
+		{
+			name:   "Put",
+			method: http.MethodPut,
+			query:  "?level=error&modules=db=debug,*=info",
+			code:   http.StatusOK,
+			want:   `{"level":"ERROR","modules":{"*":"INFO","db":"DEBUG"}}`,
+		},
+		{
+			name:   "Unknown level",
+			method: http.MethodPost,
+			body:   `{"level":"warn","modules":{"db":"verbose"}}`,
+			code:   http.StatusBadRequest,
+			want:   `{"error":"otris: unknown level \"verbose\""}`,
+		},
+		{
+			name:   "Unchanged after error",
+			method: http.MethodGet,
+			code:   http.StatusOK,
+			want:   `{"level":"ERROR","modules":{"*":"INFO","db":"DEBUG"}}`,
+		},
+		{
+			name:   "Method not allowed",
+			method: http.MethodDelete,
+			code:   http.StatusMethodNotAllowed,
+			want:   `{"error":"method DELETE is not allowed"}`,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, srv.URL+test.query, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var got json.RawMessage
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != test.code || string(got) != test.want {
+				t.Errorf("\ngot  %d %s\nwant %d %s", resp.StatusCode, got, test.code, test.want)
+			}
+		})
+	}
+}
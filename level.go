@@ -1,6 +1,15 @@
 package otris
 
-import "log/slog"
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // LevelFx and LevelFxError represents a custom logging level for FxHandler. It has a value of -8 and -7.
 const (
@@ -12,28 +21,170 @@ const (
 	LevelError   = slog.LevelError
 )
 
+// LevelDef describes a level in the level registry.
+type LevelDef struct {
+	Level     slog.Level
+	Name      string   // Name of the level, e.g. "TRACE"
+	ShortName string   // Short name of the level for compact layouts, e.g. "TRC", defaults to Name
+	Color     LogColor // Color of the level, used when the color map of the handler has no color for it
+}
+
+// levelRegistry is an immutable snapshot of the registered levels, sorted by level.
+type levelRegistry struct {
+	defs   []LevelDef
+	byName map[string]slog.Level // upper-case names and short names
+}
+
+var (
+	registry   atomic.Pointer[levelRegistry]
+	registryMu sync.Mutex // serializes RegisterLevel
+)
+
+func init() {
+	defs := []LevelDef{
+		{Level: LevelFx, Name: "FX", ShortName: "FX", Color: LogColor(color.FgCyan)},
+		{Level: LevelFxError, Name: "FXError", ShortName: "FXE", Color: LogColor(color.FgHiRed)},
+		{Level: LevelDebug, Name: "DEBUG", ShortName: "DBG", Color: LogColor(color.FgBlue)},
+		{Level: LevelInfo, Name: "INFO", ShortName: "INF", Color: LogColor(color.FgHiGreen)},
+		{Level: LevelWarning, Name: "WARN", ShortName: "WRN", Color: LogColor(color.FgYellow)},
+		{Level: LevelError, Name: "ERROR", ShortName: "ERR", Color: LogColor(color.FgRed)},
+	}
+	registry.Store(newLevelRegistry(defs))
+}
+
+func newLevelRegistry(defs []LevelDef) *levelRegistry {
+	slices.SortFunc(defs, func(a, b LevelDef) int { return int(a.Level) - int(b.Level) })
+	r := &levelRegistry{defs: defs, byName: make(map[string]slog.Level, 2*len(defs))}
+	for _, def := range defs {
+		r.byName[strings.ToUpper(def.Name)] = def.Level
+		r.byName[strings.ToUpper(def.ShortName)] = def.Level
+	}
+	return r
+}
+
+// lookup returns the definition of the level.
+func (r *levelRegistry) lookup(level slog.Level) (LevelDef, bool) {
+	i, ok := slices.BinarySearchFunc(r.defs, level, func(def LevelDef, l slog.Level) int { return int(def.Level) - int(l) })
+	if !ok {
+		return LevelDef{}, false
+	}
+	return r.defs[i], true
+}
+
+// base returns the level the unregistered level is named after, that is the nearest lower
+// of DEBUG, INFO, WARN and ERROR, or DEBUG like slog.Level.String, e.g. "DEBUG-1" instead of "FXError+2".
+func (r *levelRegistry) base(level slog.Level) LevelDef {
+	base := LevelDebug
+	for _, l := range []slog.Level{LevelInfo, LevelWarning, LevelError} {
+		if level >= l {
+			base = l
+		}
+	}
+	def, _ := r.lookup(base)
+	return def
+}
+
+// RegisterLevel adds the level to the level registry, or replaces the definition of a registered level.
+// The registered levels are used by GetLevelName, GetLevelShortName, GetColor and ParseLevel.
+// It returns an error if the name is empty or used by another level.
+// Usually the levels are registered once at the start of the application.
+func RegisterLevel(def LevelDef) error {
+	if def.Name == "" {
+		return fmt.Errorf("otris: empty name of level %d", int(def.Level))
+	}
+	if def.ShortName == "" {
+		def.ShortName = def.Name
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	r := registry.Load()
+	for _, name := range []string{def.Name, def.ShortName} {
+		if lvl, ok := r.byName[strings.ToUpper(name)]; ok && lvl != def.Level {
+			return fmt.Errorf("otris: level name %q is used by level %d", name, int(lvl))
+		}
+	}
+	defs := slices.DeleteFunc(slices.Clone(r.defs), func(d LevelDef) bool { return d.Level == def.Level })
+	registry.Store(newLevelRegistry(append(defs, def)))
+	return nil
+}
+
+// Levels returns the registered levels ordered from the lowest to the highest.
+func Levels() []LevelDef {
+	return slices.Clone(registry.Load().defs)
+}
+
 // GetLevelName takes a slog.Level as input and returns the corresponding name as a string.
 // If the level is LevelFx, it returns "FX". If the level is LevelFxError, it returns "FXError".
-// For any other level, it returns the string representation of the level.
+// For any other registered level, it returns the registered name.
+// For the unregistered levels, it returns the name of the nearest lower standard level with the offset, e.g. "INFO+2" or "DEBUG-1".
+// The registered custom levels aren't used as the base, like slog.Level.String, e.g. -10 is "DEBUG-6"
+// even if TRACE is -12, and the levels below DEBUG are named after it. ParseLevel accepts the returned names.
 func GetLevelName(level slog.Level) (name string) {
-	switch level {
-	case LevelFx:
-		name = "FX"
-	case LevelFxError:
-		name = "FXError"
-	default:
-		name = level.String()
+	return levelName(level, false)
+}
+
+// GetLevelShortName returns the short name of the level, e.g. "DBG", with the offset for unregistered levels.
+func GetLevelShortName(level slog.Level) string {
+	return levelName(level, true)
+}
+
+func levelName(level slog.Level, short bool) string {
+	r := registry.Load()
+	def, ok := r.lookup(level)
+	if !ok {
+		def = r.base(level)
+	}
+	name := def.Name
+	if short {
+		name = def.ShortName
 	}
-	return name
+	if ok {
+		return name
+	}
+	return fmt.Sprintf("%s%+d", name, int(level-def.Level))
 }
 
 // GetColor returns the integer value of the LogColor associated with the given slog.Level in the LevelColorMap.
-// If the given slog.Level is not found in the LevelColorMap, it returns the integer value of color.FgWhite (37).
+// It's GetLogColor converted to int.
 func GetColor(m LevelColorMap, lvl slog.Level) int {
-	color, ok := m[lvl]
-	if !ok {
-		// color.FgWhite == 37
-		return 37
+	return int(GetLogColor(m, lvl))
+}
+
+// GetLogColor returns the LogColor associated with the given slog.Level in the LevelColorMap.
+// If the given slog.Level is not found in the non-empty LevelColorMap, it returns the color of the registered level.
+// Otherwise, it returns color.FgWhite.
+func GetLogColor(m LevelColorMap, lvl slog.Level) LogColor {
+	c, ok := m[lvl]
+	if ok {
+		return c
+	}
+	if len(m) > 0 {
+		if def, ok := registry.Load().lookup(lvl); ok && def.Color != 0 {
+			return def.Color
+		}
+	}
+	return LogColor(color.FgWhite)
+}
+
+// ParseLevel is the inverse of GetLevelName and GetLevelShortName, it ignores the case.
+// It also accepts the names with offsets, e.g. "DEBUG+2", and the numeric levels.
+// ParseLevel can be used to read levels from config files and environment variables.
+func ParseLevel(name string) (slog.Level, error) {
+	name = strings.TrimSpace(name)
+	if n, err := strconv.Atoi(name); err == nil {
+		return slog.Level(n), nil
+	}
+	r := registry.Load()
+	upper := strings.ToUpper(name)
+	if lvl, ok := r.byName[upper]; ok {
+		return lvl, nil
+	}
+	if i := strings.LastIndexAny(upper, "+-"); i > 0 {
+		lvl, ok := r.byName[upper[:i]]
+		offset, err := strconv.Atoi(upper[i:])
+		if ok && err == nil {
+			return lvl + slog.Level(offset), nil
+		}
 	}
-	return int(color)
+	return 0, fmt.Errorf("otris: unknown level %q", name)
 }
@@ -0,0 +1,142 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"github.com/fatih/color"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const (
+	levelTrace = slog.Level(-12)
+	levelFatal = slog.Level(12)
+)
+
+// registerTestLevels registers the TRACE and FATAL levels, the registry is restored after the test.
+func registerTestLevels(t *testing.T) {
+	t.Helper()
+	saved := registry.Load()
+	t.Cleanup(func() { registry.Store(saved) })
+	for _, def := range []LevelDef{
+		{Level: levelTrace, Name: "TRACE", ShortName: "TRC", Color: LogColor(color.FgMagenta)},
+		{Level: levelFatal, Name: "FATAL", ShortName: "FTL", Color: LogColor(color.BgRed)},
+	} {
+		if err := RegisterLevel(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetLevelName(t *testing.T) {
+	registerTestLevels(t)
+
+	// Test cases
+	cases := []struct {
+		level slog.Level
+		name  string
+		short string
+	}{
+		{level: levelTrace, name: "TRACE", short: "TRC"},
+		{level: levelTrace - 1, name: "DEBUG-9", short: "DBG-9"},
+		{level: LevelFx, name: "FX", short: "FX"},
+		{level: LevelFxError, name: "FXError", short: "FXE"},
+		{level: LevelDebug - 1, name: "DEBUG-1", short: "DBG-1"},
+		{level: LevelDebug - 2, name: "DEBUG-2", short: "DBG-2"},
+		{level: LevelDebug - 6, name: "DEBUG-6", short: "DBG-6"},
+		{level: LevelDebug + 2, name: "DEBUG+2", short: "DBG+2"},
+		{level: LevelInfo, name: "INFO", short: "INF"},
+		{level: LevelError + 2, name: "ERROR+2", short: "ERR+2"},
+		{level: levelFatal, name: "FATAL", short: "FTL"},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GetLevelName(test.level); got != test.name {
+				t.Errorf("\ngot  %s\nwant %s", got, test.name)
+			}
+			if got := GetLevelShortName(test.level); got != test.short {
+				t.Errorf("\ngot  %s\nwant %s", got, test.short)
+			}
+			for _, name := range []string{test.name, test.short} {
+				got, err := ParseLevel(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != test.level {
+					t.Errorf("\ngot  %d\nwant %d for %s", got, test.level, name)
+				}
+			}
+		})
+	}
+}
+
+func TestLevelNameRoundTrip(t *testing.T) {
+	registerTestLevels(t)
+
+	for level := slog.Level(-20); level <= 20; level++ {
+		for _, name := range []string{GetLevelName(level), GetLevelShortName(level)} {
+			if got, err := ParseLevel(name); err != nil || got != level {
+				t.Errorf("\ngot  %d %v\nwant %d for %s", got, err, level, name)
+			}
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	registerTestLevels(t)
+
+	// Test cases
+	cases := []struct {
+		name  string
+		level slog.Level
+		ok    bool
+	}{
+		{name: "trace", level: levelTrace, ok: true},
+		{name: " Fatal ", level: levelFatal, ok: true},
+		{name: "fxerror", level: LevelFxError, ok: true},
+		{name: "warn-1", level: LevelWarning - 1, ok: true},
+		{name: "-3", level: -3, ok: true},
+		{name: "verbose", ok: false},
+		{name: "INFO+x", ok: false},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLevel(test.name)
+			if (err == nil) != test.ok || got != test.level {
+				t.Errorf("\ngot  %d %v\nwant %d %t", got, err, test.level, test.ok)
+			}
+		})
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	registerTestLevels(t)
+
+	if err := RegisterLevel(LevelDef{Level: 5, Name: "trace"}); err == nil {
+		t.Error("registered the name of another level")
+	}
+	if err := RegisterLevel(LevelDef{Level: 5}); err == nil {
+		t.Error("registered the empty name")
+	}
+	if got := GetLogColor(DefaultColorMap, levelFatal); got != LogColor(color.BgRed) {
+		t.Errorf("\ngot  %d\nwant %d", got, color.BgRed)
+	}
+	if got := GetLogColor(EmptyColorMap, levelFatal); got != LogColor(color.FgWhite) {
+		t.Errorf("\ngot  %d\nwant %d", got, color.FgWhite)
+	}
+	if got := GetColor(DefaultColorMap, levelFatal); got != int(color.BgRed) {
+		t.Errorf("\ngot  %d\nwant %d", got, color.BgRed)
+	}
+
+	var got bytes.Buffer
+	h := NewJSONHandler(&got, &slog.HandlerOptions{Level: levelTrace})
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Time{}, levelFatal, "message", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"level\":\"FATAL\",\"msg\":\"message\"}\n"; got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+}
@@ -0,0 +1,101 @@
+package otris
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// Lifecycle is implemented by the handlers and the writers that buffer the records,
+// it's used to not lose the last records when the application stops.
+//
+// Usage:
+//
+//	handler := NewHandlerBuilder().WithAsync(0, OverflowBlock).Build()
+//	defer handler.Close()
+type Lifecycle interface {
+	// Flush writes the buffered records to the underlying writer.
+	Flush() error
+	// Sync flushes and commits the written records to the stable storage.
+	Sync() error
+	// Close flushes, syncs and releases the resources, e.g. the goroutines and the files.
+	Close() error
+}
+
+var _ Lifecycle = (*Handler)(nil)
+var _ Lifecycle = (*AsyncWriter)(nil)
+
+// Flush writes the buffered records to the writer and flushes the writer if it has a `Flush() error` method,
+// e.g. *bufio.Writer. The Handler and its clones share the writer, so they are flushed together.
+// The summaries of the records dropped by the sampler and suppressed by the dedup mode are logged before.
+func (h *Handler) Flush() error {
+	h.flushSampler()
+	h.flushDedup()
+	if w, ok := h.w.(*AsyncWriter); ok {
+		return w.Flush()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return flushWriter(h.w)
+}
+
+// Sync flushes the Handler and commits the writer to the stable storage if it has a `Sync() error` method,
+// e.g. *os.File. The errors of the writers that can't be synced, like terminals and pipes, are ignored.
+func (h *Handler) Sync() error {
+	h.flushSampler()
+	h.flushDedup()
+	if w, ok := h.w.(*AsyncWriter); ok {
+		return w.Sync()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return syncWriter(h.w)
+}
+
+// Close syncs the Handler, stops the goroutine of the async mode and closes the writer if it's an io.Closer,
+// except os.Stdout and os.Stderr. The Handler and its clones must not be used after Close,
+// unless the writer is still open, e.g. os.Stdout. The writer is closed once, by the first Close
+// of the Handler or its clones.
+func (h *Handler) Close() error {
+	h.flushSampler()
+	h.flushDedup()
+	if w, ok := h.w.(*AsyncWriter); ok {
+		return w.Close()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if *h.closed {
+		return nil
+	}
+	*h.closed = true
+	return closeWriter(h.w)
+}
+
+func flushWriter(w io.Writer) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func syncWriter(w io.Writer) error {
+	err := flushWriter(w)
+	if s, ok := w.(interface{ Sync() error }); ok {
+		if serr := s.Sync(); serr != nil && !errors.Is(serr, syscall.EINVAL) && !errors.Is(serr, syscall.ENOTSUP) {
+			err = errors.Join(err, serr)
+		}
+	}
+	return err
+}
+
+func closeWriter(w io.Writer) error {
+	err := syncWriter(w)
+	if w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+		return err
+	}
+	if c, ok := w.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
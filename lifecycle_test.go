@@ -0,0 +1,55 @@
+package otris
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// closeRecorder records the Close calls.
+type closeRecorder struct {
+	bytes.Buffer
+	closed int
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed++
+	return nil
+}
+
+func TestHandlerLifecycle(t *testing.T) {
+	var got closeRecorder
+	bw := bufio.NewWriter(&got)
+	h := NewHandlerBuilder().WithWriter(bw).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).Build()
+	logger := slog.New(h).WithGroup("g")
+
+	logger.Info("message")
+	if got.Len() != 0 {
+		t.Fatalf("the record is written before Flush: %s", got.String())
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "level=INFO msg=message\n"; got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+
+	// The writer is closed once by the Handler and its clones.
+	for _, b := range []*HandlerBuilder{
+		NewHandlerBuilder(),
+		NewHandlerBuilder().WithAsync(0, OverflowBlock),
+	} {
+		got.closed = 0
+		h = b.WithWriter(&got).Build()
+		clone := slog.New(h).With("k", 1).Handler().(*Handler)
+		for _, h := range []*Handler{h, h, clone} {
+			if err := h.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got.closed != 1 {
+			t.Errorf("the writer is closed %d times, want 1", got.closed)
+		}
+	}
+}
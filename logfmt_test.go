@@ -0,0 +1,166 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// decodeLogfmt is a strict logfmt decoder used to check the conformance of the logfmt handler.
+// It follows the grammar of github.com/go-logfmt/logfmt: pairs are separated by a single space,
+// keys are bare, values are bare or quoted with the JSON escaping.
+func decodeLogfmt(line []byte) ([][2]string, error) {
+	if !bytes.HasSuffix(line, []byte{'\n'}) {
+		return nil, errors.New("missing newline")
+	}
+	line = line[:len(line)-1]
+	var pairs [][2]string
+	for i := 0; i < len(line); {
+		if i > 0 {
+			if line[i] != ' ' {
+				return nil, fmt.Errorf("expected space at %d", i)
+			}
+			i++
+		}
+		start := i
+		for i < len(line) && isLogfmtIdent(line[i]) {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("empty key at %d", i)
+		}
+		key := string(line[start:i])
+		if i == len(line) || line[i] != '=' {
+			return nil, fmt.Errorf("expected '=' at %d", i)
+		}
+		i++
+		var val string
+		if i < len(line) && line[i] == '"' {
+			start = i
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+			if i == len(line) {
+				return nil, errors.New("unterminated quoted value")
+			}
+			i++
+			if err := json.Unmarshal(line[start:i], &val); err != nil {
+				return nil, err
+			}
+		} else {
+			start = i
+			for i < len(line) && isLogfmtIdent(line[i]) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("empty bare value at %d", i)
+			}
+			val = string(line[start:i])
+		}
+		if !utf8.ValidString(key) || !utf8.ValidString(val) {
+			return nil, errors.New("invalid UTF-8")
+		}
+		pairs = append(pairs, [2]string{key, val})
+	}
+	return pairs, nil
+}
+
+func isLogfmtIdent(b byte) bool {
+	return b > ' ' && b != '=' && b != '"' && b != 0x7f
+}
+
+func TestNewLogfmtHandler(t *testing.T) {
+	ctx := context.Background()
+	tm := time.Date(2024, 7, 26, 5, 26, 24, 0, time.UTC)
+
+	// Test cases
+	cases := []struct {
+		name    string
+		handler func(h slog.Handler) slog.Handler
+		attrs   []slog.Attr
+		want    [][2]string
+	}{
+		{
+			name:  "Plain",
+			attrs: []slog.Attr{slog.Int("a", 1), slog.String("b", "two"), slog.Bool("c", true), slog.Float64("d", 85.7)},
+			want:  [][2]string{{"a", "1"}, {"b", "two"}, {"c", "true"}, {"d", "85.7"}},
+		},
+		{
+			name:  "Quoting",
+			attrs: []slog.Attr{slog.String("space", "a b"), slog.String("eq", "a=b"), slog.String("quote", `say "hi"`), slog.String("empty", "")},
+			want:  [][2]string{{"space", "a b"}, {"eq", "a=b"}, {"quote", `say "hi"`}, {"empty", ""}},
+		},
+		{
+			name:  "Escaping",
+			attrs: []slog.Attr{slog.String("nl", "a\nb"), slog.String("tab", "a\tb"), slog.String("bs", `C:\tmp`), slog.String("ctl", "a\x00\x1bb")},
+			want:  [][2]string{{"nl", "a\nb"}, {"tab", "a\tb"}, {"bs", `C:\tmp`}, {"ctl", "a\x00\x1bb"}},
+		},
+		{
+			name:  "Unicode",
+			attrs: []slog.Attr{slog.String("jp", "接続エラー"), slog.String("nbsp", "a\u00a0b"), slog.String("bad", "a\xffb")},
+			want:  [][2]string{{"jp", "接続エラー"}, {"nbsp", "a\u00a0b"}, {"bad", "a\ufffdb"}},
+		},
+		{
+			name:  "Keys",
+			attrs: []slog.Attr{slog.String("a key", "1"), slog.String("a=b", "2"), slog.String(`"q"`, "3"), slog.Int("", 4)},
+			want:  [][2]string{{"a_key", "1"}, {"a_b", "2"}, {"_q_", "3"}, {"_", "4"}},
+		},
+		{
+			name: "Groups",
+			handler: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("pre", "x y")}).WithGroup("req")
+			},
+			attrs: []slog.Attr{slog.Group("http", slog.Int("code", 404)), slog.Any("body", []byte("a b"))},
+			want:  [][2]string{{"pre", "x y"}, {"req.http.code", "404"}, {"req.body", "a b"}},
+		},
+		{
+			name:  "Values",
+			attrs: []slog.Attr{slog.Duration("dur", 1500*time.Millisecond), slog.Time("at", tm), slog.Any("err", errors.New("boom: x=1"))},
+			want:  [][2]string{{"dur", "1.5s"}, {"at", "2024-07-26T05:26:24.000Z"}, {"err", "boom: x=1"}},
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			for _, build := range []func(buf *bytes.Buffer) slog.Handler{
+				func(buf *bytes.Buffer) slog.Handler { return NewLogfmtHandler(buf, nil) },
+				func(buf *bytes.Buffer) slog.Handler {
+					return NewHandlerBuilder().WithPretty().WithSeparator(" | ").WithLogfmt().WithWriter(buf).Build()
+				},
+			} {
+				var got bytes.Buffer
+				h := build(&got)
+				if test.handler != nil {
+					h = test.handler(h)
+				}
+
+				r := slog.NewRecord(tm, LevelFxError, "hello world", 0)
+				r.AddAttrs(test.attrs...)
+				if err := h.Handle(ctx, r); err != nil {
+					t.Fatal(err)
+				}
+
+				pairs, err := decodeLogfmt(got.Bytes())
+				if err != nil {
+					t.Fatalf("%v in %q", err, got.String())
+				}
+				want := append([][2]string{
+					{"time", "2024-07-26T05:26:24.000Z"},
+					{"level", "FXError"},
+					{"msg", "hello world"},
+				}, test.want...)
+				if fmt.Sprint(pairs) != fmt.Sprint(want) {
+					t.Errorf("\ngot  %q\nwant %q", pairs, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package otris
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// ModuleKey is the key of the attribute that tags the loggers and the records with a module name.
+// The fx logger emits it for the fx events of the modules.
+const ModuleKey = "module"
+
+// AnyModule is the rule key of the default level for all modules.
+const AnyModule = "*"
+
+// ModuleLevels holds the minimum levels of the modules, it can be changed at runtime
+// and is shared by the handlers built with it and their clones.
+// The module of a logger is the path of its groups from WithGroup, e.g. "db.sql",
+// or the value of the ModuleKey attribute from WithAttrs or from the record.
+// The rules are hierarchical: "db.sql" falls back to "db" and then to "*".
+// ModuleLevels implements flag.Value.
+//
+// Usage:
+//
+//	modules, err := NewModuleLevels("db=debug,http=warn,*=info")
+//	handler := NewHandlerBuilder().WithModuleLevels(modules).Build()
+//	modules.SetLevel("http", LevelDebug)
+type ModuleLevels struct {
+	rules atomic.Pointer[moduleRules]
+}
+
+// moduleRules is an immutable snapshot of the rules.
+type moduleRules struct {
+	levels map[string]slog.Level
+	min    slog.Level // minimum level of all rules
+}
+
+func newModuleRules(levels map[string]slog.Level) *moduleRules {
+	r := &moduleRules{levels: levels}
+	first := true
+	for _, lvl := range levels {
+		if first || lvl < r.min {
+			r.min = lvl
+			first = false
+		}
+	}
+	return r
+}
+
+// NewModuleLevels creates ModuleLevels from the rule set like "db=debug,http=warn,*=info".
+func NewModuleLevels(spec string) (*ModuleLevels, error) {
+	m := &ModuleLevels{}
+	if err := m.Set(spec); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ParseModuleLevels parses the rule set like "db=debug,http=warn,*=info".
+// A level without a module, e.g. "info", is the rule for all modules.
+func ParseModuleLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		module, name, ok := strings.Cut(rule, "=")
+		if !ok {
+			module, name = AnyModule, rule
+		}
+		module = strings.TrimSpace(module)
+		if module == "" {
+			return nil, fmt.Errorf("otris: empty module in rule %q", rule)
+		}
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[module] = lvl
+	}
+	return levels, nil
+}
+
+// Set replaces all rules with the rule set like "db=debug,http=warn,*=info".
+func (m *ModuleLevels) Set(spec string) error {
+	levels, err := ParseModuleLevels(spec)
+	if err != nil {
+		return err
+	}
+	m.rules.Store(newModuleRules(levels))
+	return nil
+}
+
+// SetLevel sets the level of the module, AnyModule sets the default level.
+func (m *ModuleLevels) SetLevel(module string, level slog.Level) {
+	m.update(func(levels map[string]slog.Level) { levels[module] = level })
+}
+
+// Remove removes the rule of the module.
+func (m *ModuleLevels) Remove(module string) {
+	m.update(func(levels map[string]slog.Level) { delete(levels, module) })
+}
+
+func (m *ModuleLevels) update(f func(levels map[string]slog.Level)) {
+	for {
+		old := m.rules.Load()
+		levels := make(map[string]slog.Level)
+		if old != nil {
+			levels = maps.Clone(old.levels)
+		}
+		f(levels)
+		if m.rules.CompareAndSwap(old, newModuleRules(levels)) {
+			return
+		}
+	}
+}
+
+// Rules returns a copy of the rules.
+func (m *ModuleLevels) Rules() map[string]slog.Level {
+	r := m.rules.Load()
+	if r == nil {
+		return map[string]slog.Level{}
+	}
+	return maps.Clone(r.levels)
+}
+
+// Level returns the level of the module, it falls back to the parent modules and then to AnyModule.
+// It reports false if no rule matches the module.
+func (m *ModuleLevels) Level(module string) (slog.Level, bool) {
+	r := m.rules.Load()
+	if r == nil {
+		return 0, false
+	}
+	for module != "" {
+		if lvl, ok := r.levels[module]; ok {
+			return lvl, true
+		}
+		i := strings.LastIndexByte(module, keyComponentSep)
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	lvl, ok := r.levels[AnyModule]
+	return lvl, ok
+}
+
+// minLevel returns the minimum level of all rules.
+func (m *ModuleLevels) minLevel() (slog.Level, bool) {
+	r := m.rules.Load()
+	if r == nil || len(r.levels) == 0 {
+		return 0, false
+	}
+	return r.min, true
+}
+
+// String returns the rule set sorted by module, e.g. "*=INFO,db=DEBUG,http=WARN".
+func (m *ModuleLevels) String() string {
+	if m == nil {
+		return ""
+	}
+	levels := m.Rules()
+	modules := make([]string, 0, len(levels))
+	for module := range levels {
+		modules = append(modules, module)
+	}
+	slices.Sort(modules)
+	var sb strings.Builder
+	for _, module := range modules {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(module)
+		sb.WriteByte('=')
+		sb.WriteString(GetLevelName(levels[module]))
+	}
+	return sb.String()
+}
+
+// moduleOf returns the value of the ModuleKey attribute.
+func moduleOf(a slog.Attr) (string, bool) {
+	if a.Key != ModuleKey {
+		return "", false
+	}
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindString {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// recordModule returns the value of the first ModuleKey attribute of the record.
+func recordModule(r slog.Record) (module string) {
+	r.Attrs(func(a slog.Attr) bool {
+		m, ok := moduleOf(a)
+		if ok {
+			module = m
+		}
+		return !ok
+	})
+	return module
+}
+
+// joinModule appends the group to the module path.
+func joinModule(module, group string) string {
+	if module == "" {
+		return group
+	}
+	return module + string(keyComponentSep) + group
+}
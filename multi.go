@@ -0,0 +1,238 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Totus-Floreo/otris/internal/slog/buffer"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// MultiHandler dispatches the records to several handlers, e.g. the pretty Handler to the console
+// and the JSON Handler to a file, each with its own level and writer.
+// The values of the attributes are resolved once, so the slog.LogValuer values are called once per record.
+// The *Handler handlers with the same format, e.g. built by one HandlerBuilder with different writers
+// and levels, format the record once and write the same bytes to their writers.
+//
+// Usage:
+//
+//	console := NewHandlerBuilder().WithPretty().Build()
+//	file := NewHandlerBuilder().WithJSON().WithWriter(f).Build()
+//	logger := slog.New(NewMultiHandler(console, file))
+type MultiHandler struct {
+	handlers []slog.Handler
+	formats  []int // index of the first *Handler with the same format as the handler, or -1
+}
+
+var _ Lifecycle = (*MultiHandler)(nil)
+
+// NewMultiHandler creates a new MultiHandler dispatching to the handlers, the nil handlers are skipped.
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	m := &MultiHandler{handlers: make([]slog.Handler, 0, len(handlers))}
+	for _, h := range handlers {
+		if h != nil {
+			m.handlers = append(m.handlers, h)
+		}
+	}
+	m.indexFormats()
+	return m
+}
+
+// indexFormats groups the *Handler handlers by their format.
+func (m *MultiHandler) indexFormats() {
+	m.formats = make([]int, len(m.handlers))
+	for i, h := range m.handlers {
+		m.formats[i] = -1
+		oh, ok := h.(*Handler)
+		if !ok {
+			continue
+		}
+		m.formats[i] = i
+		for j := range m.handlers[:i] {
+			if first, ok := m.handlers[j].(*Handler); ok && m.formats[j] == j && oh.sameFormat(first) {
+				m.formats[i] = j
+				break
+			}
+		}
+	}
+}
+
+// Handlers returns the handlers of the MultiHandler, the returned slice must not be modified.
+func (m *MultiHandler) Handlers() []slog.Handler {
+	return m.handlers
+}
+
+// Enabled reports whether any handler handles records at the given level.
+func (m *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle resolves the attributes of the record and dispatches it to the enabled handlers.
+// The *Handler handlers with the same format share the formatted record.
+// It returns the joined errors of the handlers.
+func (m *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var resolved *slog.Record
+	var ctxAttrs []slog.Attr
+	var lines []*formattedRecord
+	var errs []error
+	for i, h := range m.handlers {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if resolved == nil {
+			resolved = resolveRecord(r)
+			ctxAttrs = ContextAttrs(ctx)
+		}
+		oh, ok := h.(*Handler)
+		if !ok || i >= len(m.formats) {
+			if err := h.Handle(ctx, resolved.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if !oh.allow(*resolved, ctxAttrs) {
+			continue
+		}
+		if lines == nil {
+			lines = make([]*formattedRecord, len(m.handlers))
+		}
+		line := lines[m.formats[i]]
+		if line == nil {
+			line = &formattedRecord{state: oh.newHandleState(buffer.New(), true, "")}
+			line.keyStart = oh.format(&line.state, ctx, *resolved, ctxAttrs)
+			lines[m.formats[i]] = line
+		}
+		if err := oh.write(*line.state.buf, line.keyStart, *resolved); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, line := range lines {
+		if line != nil {
+			line.state.free()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formattedRecord is the record formatted by Handler.format.
+type formattedRecord struct {
+	state    handleState
+	keyStart int
+}
+
+// WithAttrs returns a new MultiHandler with the attributes added to all handlers.
+func (m *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return m
+	}
+	attrs = resolveAttrs(attrs)
+	m2 := &MultiHandler{handlers: make([]slog.Handler, len(m.handlers))}
+	for i, h := range m.handlers {
+		m2.handlers[i] = h.WithAttrs(attrs)
+	}
+	m2.indexFormats()
+	return m2
+}
+
+// WithGroup returns a new MultiHandler with the group opened in all handlers.
+func (m *MultiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return m
+	}
+	m2 := &MultiHandler{handlers: make([]slog.Handler, len(m.handlers))}
+	for i, h := range m.handlers {
+		m2.handlers[i] = h.WithGroup(name)
+	}
+	m2.indexFormats()
+	return m2
+}
+
+// Flush flushes all handlers that implement Lifecycle.
+func (m *MultiHandler) Flush() error {
+	return m.each(Lifecycle.Flush)
+}
+
+// Sync syncs all handlers that implement Lifecycle.
+func (m *MultiHandler) Sync() error {
+	return m.each(Lifecycle.Sync)
+}
+
+// Close closes all handlers that implement Lifecycle.
+func (m *MultiHandler) Close() error {
+	return m.each(Lifecycle.Close)
+}
+
+func (m *MultiHandler) each(f func(Lifecycle) error) error {
+	var errs []error
+	for _, h := range m.handlers {
+		if l, ok := h.(Lifecycle); ok {
+			errs = append(errs, f(l))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sameFormat reports whether the handlers format every record to the same bytes.
+// The functions, the color maps of the values and the columns are compared by identity.
+func (h *Handler) sameFormat(o *Handler) bool {
+	return h.json == o.json && h.pretty == o.pretty && h.logfmt == o.logfmt && h.safe == o.safe &&
+		h.sep == o.sep && h.layout == o.layout && h.shortLevels == o.shortLevels &&
+		h.prettyKeys == o.prettyKeys && h.keyColor == o.keyColor && h.multiline == o.multiline &&
+		h.maxInline == o.maxInline && h.repeatColor == o.repeatColor && h.colorMode == o.colorMode &&
+		maps.Equal(h.color, o.color) && maps.Equal(h.valueOnly, o.valueOnly) &&
+		sameMap(h.valueColor, o.valueColor) && sameMap(h.columns, o.columns) &&
+		sameOptions(h.opts, o.opts) && sameSlice(h.extractors, o.extractors) && h.trace == nil && o.trace == nil &&
+		bytes.Equal(h.preformattedAttrs, o.preformattedAttrs) && bytes.Equal(h.preformattedTail, o.preformattedTail) &&
+		h.groupPrefix == o.groupPrefix && slices.Equal(h.groups, o.groups) && h.nOpenGroups == o.nOpenGroups
+}
+
+// sameOptions reports whether the options format the records identically, ReplaceAttr is compared by the options.
+func sameOptions(a, b *slog.HandlerOptions) bool {
+	return a == b || a.ReplaceAttr == nil && b.ReplaceAttr == nil && a.AddSource == b.AddSource
+}
+
+func sameMap[M ~map[K]V, K comparable, V any](a, b M) bool {
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
+
+func sameSlice[S ~[]E, E any](a, b S) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// resolveRecord returns a copy of the record with the resolved attributes.
+func resolveRecord(r slog.Record) *slog.Record {
+	resolved := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, resolveAttr(a))
+		return true
+	})
+	resolved.AddAttrs(attrs...)
+	return &resolved
+}
+
+// resolveAttrs returns a copy of the attributes with the resolved values.
+func resolveAttrs(attrs []slog.Attr) []slog.Attr {
+	resolved := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		resolved[i] = resolveAttr(a)
+	}
+	return resolved
+}
+
+// resolveAttr resolves the value of the attribute and of the attributes of its groups.
+func resolveAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		a.Value = slog.GroupValue(resolveAttrs(a.Value.Group())...)
+	}
+	return a
+}
@@ -0,0 +1,101 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingValuer counts the calls of LogValue.
+type countingValuer struct {
+	calls *int
+}
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.calls++
+	return slog.StringValue("resolved")
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk is full")
+}
+
+func TestMultiHandler(t *testing.T) {
+	var console, file bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+	pretty := NewHandlerBuilder().WithPretty().WithWriter(&console).WithOptions(opts).Build()
+	json := NewHandlerBuilder().WithJSON().WithWriter(&file).
+		WithOptions(&slog.HandlerOptions{Level: LevelWarning, ReplaceAttr: removeTime}).Build()
+
+	calls := 0
+	logger := slog.New(NewMultiHandler(pretty, json, nil)).With("pre", countingValuer{&calls}).WithGroup("g")
+	logger.Info("info", "a", countingValuer{&calls})
+	logger.Warn("warn", "a", slog.GroupValue(slog.Any("b", countingValuer{&calls})))
+
+	if want := "INFO | info | resolved | resolved\nWARN | warn | resolved | resolved\n"; console.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", console.String(), want)
+	}
+	if want := `{"level":"WARN","msg":"warn","pre":"resolved","g":{"a":{"b":"resolved"}}}` + "\n"; file.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", file.String(), want)
+	}
+	if calls != 3 {
+		t.Errorf("LogValue is called %d times, want 3", calls)
+	}
+
+	if slog.New(NewMultiHandler(json)).Enabled(context.Background(), LevelInfo) {
+		t.Error("MultiHandler is enabled for the level of no handler")
+	}
+}
+
+func TestMultiHandlerErrors(t *testing.T) {
+	var got bytes.Buffer
+	ok := NewHandlerBuilder().WithWriter(&got).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).Build()
+	failing := NewHandlerBuilder().WithWriter(failingWriter{}).Build()
+	m := NewMultiHandler(failing, ok, failing)
+
+	err := m.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "message", 0))
+	if err == nil || strings.Count(err.Error(), "disk is full") != 2 {
+		t.Errorf("unexpected error %v", err)
+	}
+	if want := "level=INFO msg=message\n"; got.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", got.String(), want)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMultiHandlerSharedFormat(t *testing.T) {
+	calls := 0
+	opts := &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		calls++
+		return removeTime(groups, a)
+	}}
+	var console, file, structured bytes.Buffer
+	m := NewMultiHandler(
+		NewHandlerBuilder().WithPretty().WithWriter(&console).WithOptions(opts).Build(),
+		NewHandlerBuilder().WithPretty().WithWriter(&file).WithOptions(opts).Build(),
+		NewHandlerBuilder().WithJSON().WithWriter(&structured).WithOptions(opts).Build(),
+	)
+	logger := slog.New(m).With("a", 1).WithGroup("g")
+	calls = 0
+	logger.Info("message", "b", 2)
+
+	// The pretty handlers share the formatted record: time, level, msg and g.b, the JSON handler formats its own.
+	if calls != 2*4 {
+		t.Errorf("ReplaceAttr is called %d times, want %d", calls, 2*4)
+	}
+	if want := "INFO | message | 1 | 2\n"; console.String() != want || file.String() != want {
+		t.Errorf("\ngot  %s%s\nwant %s", console.String(), file.String(), want)
+	}
+	if want := `{"level":"INFO","msg":"message","a":1,"g":{"b":2}}` + "\n"; structured.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", structured.String(), want)
+	}
+}
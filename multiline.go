@@ -0,0 +1,93 @@
+package otris
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// treeIndent is the indentation of a single level of the multi-line tree.
+const treeIndent = "    "
+
+// isDeferred reports whether the attribute is rendered beneath the main line in multi-line pretty mode.
+// Nested groups, multi-line strings and strings longer than maxInline are deferred.
+// The built-in attributes are never deferred, even if they pass through ReplaceAttr.
+func (s *handleState) isDeferred(a slog.Attr) bool {
+	if !s.h.pretty || !s.h.multiline || s.tail == nil || s.builtin {
+		return false
+	}
+	if s.depth > 0 {
+		return true
+	}
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		return a.Key != "" && len(a.Value.Group()) > 0
+	case slog.KindString:
+		str := a.Value.String()
+		if strings.IndexByte(str, '\n') >= 0 {
+			return true
+		}
+		return s.h.maxInline > 0 && displayWidth([]byte(str)) > s.h.maxInline
+	}
+	return false
+}
+
+// appendTreeAttr appends the attribute to the tail beneath the main line,
+// nested groups and the lines of multi-line strings are indented one level deeper.
+func (s *handleState) appendTreeAttr(a slog.Attr) {
+	buf := s.buf
+	s.buf = s.tail
+	defer func() { s.buf = buf }()
+
+	s.appendTreeKey(a.Key)
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		s.buf.WriteByte('\n')
+		if s.groups != nil {
+			*s.groups = append(*s.groups, a.Key)
+		}
+		s.depth++
+		s.buf = buf
+		for _, aa := range a.Value.Group() {
+			s.appendAttr(aa)
+		}
+		s.buf = s.tail
+		s.depth--
+		if s.groups != nil {
+			*s.groups = (*s.groups)[:len(*s.groups)-1]
+		}
+	case a.Value.Kind() == slog.KindString && strings.IndexByte(a.Value.String(), '\n') >= 0:
+		s.buf.WriteByte('\n')
+		for _, line := range strings.Split(strings.TrimRight(a.Value.String(), "\n"), "\n") {
+			s.appendTreeIndent(s.depth + 1)
+			s.buf.WriteString(line)
+			s.buf.WriteByte('\n')
+		}
+	default:
+		s.buf.WriteByte(' ')
+		if c, ok := s.valueColor(a); ok {
+			s.appendColoredValue(a.Value, c)
+		} else {
+			s.appendValue(a.Value)
+		}
+		s.buf.WriteByte('\n')
+	}
+}
+
+// appendTreeKey appends the indentation and the key of a tree line in the key color.
+// Only the top-level keys are prefixed with the groups from WithGroup.
+func (s *handleState) appendTreeKey(key string) {
+	s.appendTreeIndent(s.depth)
+	set := s.setColor(s.h.keyColor)
+	if s.depth == 0 {
+		s.buf.Write(*s.prefix)
+	}
+	s.buf.WriteString(key)
+	s.buf.WriteByte(':')
+	s.unsetColor(set)
+}
+
+func (s *handleState) appendTreeIndent(depth int) {
+	for i := 0; i <= depth; i++ {
+		s.buf.WriteString(treeIndent)
+	}
+}
@@ -0,0 +1,479 @@
+package otris
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotLogLine is returned by Parse for the lines that are not log lines produced by otris.
+var ErrNotLogLine = errors.New("otris: not a log line")
+
+// Entry is a log line read back from the otris output.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// Record returns the entry as a slog.Record, so it can be passed to any slog.Handler.
+func (e Entry) Record() slog.Record {
+	r := slog.NewRecord(e.Time, e.Level, e.Message, 0)
+	r.AddAttrs(e.Attrs...)
+	return r
+}
+
+// Parser reads the lines produced by Handler back into entries.
+// It detects JSON, struct and logfmt lines and parses pretty lines on a best-effort basis.
+//
+// Usage:
+//
+//	entry, err := NewParser().WithTimeLayout(layout).WithSeparator(sep).Parse(line)
+type Parser struct {
+	layout string
+	sep    string
+}
+
+// NewParser creates a new instance of Parser for the default pretty time layout and separator.
+func NewParser() *Parser {
+	return &Parser{
+		layout: DefaultPrettyDateTimeLayout,
+		sep:    PrettySep,
+	}
+}
+
+// WithTimeLayout sets the time layout of the pretty lines.
+// If the layout is not empty, it updates the time layout of the Parser.
+// Returns the updated Parser.
+func (p *Parser) WithTimeLayout(layout string) *Parser {
+	if layout != "" {
+		p.layout = layout
+	}
+	return p
+}
+
+// WithSeparator sets the separator of the pretty lines.
+// If the separator is not empty, it updates the separator of the Parser.
+// Returns the updated Parser.
+func (p *Parser) WithSeparator(sep string) *Parser {
+	if sep != "" {
+		p.sep = sep
+	}
+	return p
+}
+
+// Parse parses the line with the default Parser.
+func Parse(line []byte) (Entry, error) {
+	return NewParser().Parse(line)
+}
+
+// Parse parses a single line of the Handler output.
+// The ANSI escape sequences are stripped before parsing.
+// It returns ErrNotLogLine if the line isn't recognized.
+func (p *Parser) Parse(line []byte) (Entry, error) {
+	line = stripEscapes(bytes.TrimRight(line, "\r\n"))
+	switch {
+	case len(bytes.TrimSpace(line)) == 0:
+		return Entry{}, ErrNotLogLine
+	case line[0] == '{':
+		return parseJSON(line)
+	}
+	// The quoted values of the struct and logfmt lines can contain the separator,
+	// so the pretty format is tried only if the line isn't a key=value line.
+	e, err := parseKeyValue(string(line))
+	if err != nil && bytes.Contains(line, []byte(p.sep)) {
+		return p.parsePretty(string(line))
+	}
+	return e, err
+}
+
+// setBuiltin sets the built-in field of the entry for the key.
+// It reports false if the key isn't a built-in key.
+func (e *Entry) setBuiltin(key string, v slog.Value) (bool, error) {
+	switch key {
+	case slog.TimeKey:
+		if v.Kind() == slog.KindTime {
+			e.Time = v.Time()
+			return true, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, v.String())
+		if err != nil {
+			return false, err
+		}
+		e.Time = t
+	case slog.LevelKey:
+		lvl, err := ParseLevel(v.String())
+		if err != nil {
+			return false, ErrNotLogLine
+		}
+		e.Level = lvl
+	case slog.MessageKey:
+		e.Message = v.String()
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// JSON
+
+func parseJSON(line []byte) (Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Entry{}, ErrNotLogLine
+	}
+	var e Entry
+	builtins := 0
+	for dec.More() {
+		key, val, err := decodeJSONAttr(dec)
+		if err != nil {
+			return Entry{}, ErrNotLogLine
+		}
+		ok, err := e.setBuiltin(key, val)
+		if err != nil {
+			return Entry{}, err
+		}
+		if ok {
+			builtins++
+			continue
+		}
+		e.Attrs = append(e.Attrs, slog.Attr{Key: key, Value: val})
+	}
+	if builtins == 0 {
+		return Entry{}, ErrNotLogLine
+	}
+	return e, nil
+}
+
+// decodeJSONAttr decodes the next key and value of a JSON object.
+func decodeJSONAttr(dec *json.Decoder) (string, slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", slog.Value{}, err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", slog.Value{}, ErrNotLogLine
+	}
+	val, err := decodeJSONValue(dec)
+	return key, val, err
+}
+
+// decodeJSONValue decodes the next JSON value, objects are decoded as groups.
+func decodeJSONValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			var vs []any
+			for dec.More() {
+				var v any
+				if err := dec.Decode(&v); err != nil {
+					return slog.Value{}, err
+				}
+				vs = append(vs, v)
+			}
+			_, err = dec.Token()
+			return slog.AnyValue(vs), err
+		}
+		var attrs []slog.Attr
+		for dec.More() {
+			key, val, err := decodeJSONAttr(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			attrs = append(attrs, slog.Attr{Key: key, Value: val})
+		}
+		_, err = dec.Token()
+		return slog.GroupValue(attrs...), err
+	case json.Number:
+		if n, err := tok.Int64(); err == nil {
+			return slog.Int64Value(n), nil
+		}
+		f, err := tok.Float64()
+		return slog.Float64Value(f), err
+	case string:
+		return slog.StringValue(tok), nil
+	case bool:
+		return slog.BoolValue(tok), nil
+	default:
+		return slog.AnyValue(nil), nil
+	}
+}
+
+// Struct and logfmt
+
+func parseKeyValue(line string) (Entry, error) {
+	var e Entry
+	builtins := 0
+	for line != "" {
+		key, val, rest, ok := nextKeyValue(line)
+		if !ok {
+			return Entry{}, ErrNotLogLine
+		}
+		line = rest
+		ok, err := e.setBuiltin(key, val)
+		if err != nil {
+			return Entry{}, err
+		}
+		if ok {
+			builtins++
+			continue
+		}
+		e.Attrs = append(e.Attrs, slog.Attr{Key: key, Value: val})
+	}
+	if builtins == 0 {
+		return Entry{}, ErrNotLogLine
+	}
+	return e, nil
+}
+
+// nextKeyValue cuts the next `key=value` pair from the line.
+// The keys of struct lines can be quoted, as well as the values.
+func nextKeyValue(line string) (key string, val slog.Value, rest string, ok bool) {
+	line = strings.TrimLeft(line, " ")
+	key, line, ok = cutToken(line, '=')
+	if !ok || key == "" || line == "" || line[0] != '=' {
+		return "", slog.Value{}, "", false
+	}
+	if k, err := unquote(key); err == nil {
+		key = k
+	}
+	raw, rest, ok := cutToken(line[1:], ' ')
+	if !ok {
+		return "", slog.Value{}, "", false
+	}
+	if raw != "" && raw[0] == '"' {
+		s, err := unquote(raw)
+		if err != nil {
+			return "", slog.Value{}, "", false
+		}
+		return key, slog.StringValue(s), rest, true
+	}
+	return key, inferValue(raw), rest, true
+}
+
+// cutToken cuts a bare or quoted token which ends with the `end` byte.
+func cutToken(s string, end byte) (token, rest string, ok bool) {
+	if s != "" && s[0] == '"' {
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return s[:i+1], s[i+1:], true
+			}
+		}
+		return "", "", false
+	}
+	if i := strings.IndexByte(s, end); i >= 0 {
+		return s[:i], s[i:], true
+	}
+	return s, "", true
+}
+
+// unquote unquotes the Go quoted strings of the struct lines and the JSON quoted strings of the logfmt lines.
+func unquote(s string) (string, error) {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u, nil
+	}
+	var u string
+	err := json.Unmarshal([]byte(s), &u)
+	return u, err
+}
+
+// inferValue returns the typed value of a bare token.
+func inferValue(s string) slog.Value {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(n)
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return slog.Uint64Value(n)
+	}
+	if strings.ContainsAny(s, ".eE") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return slog.Float64Value(f)
+		}
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return slog.BoolValue(b)
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return slog.DurationValue(d)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return slog.TimeValue(t)
+	}
+	return slog.StringValue(s)
+}
+
+// Pretty
+
+// parsePretty parses the pretty line on a best-effort basis.
+// The time is optional, the level and the message are required.
+// The values without keys get the positional keys "0", "1", ...
+// The strings containing the separator are quoted by Handler, so the quoted fields and values are unquoted.
+// If the separator is only whitespace, the strings containing it aren't quoted and the line is split by all of them.
+func (p *Parser) parsePretty(line string) (Entry, error) {
+	fields := splitPretty(line, p.sep)
+	var e Entry
+	if t, err := time.ParseInLocation(p.layout, strings.TrimSpace(fields[0]), time.Local); err == nil {
+		e.Time = t
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return Entry{}, ErrNotLogLine
+	}
+	lvl, err := ParseLevel(fields[0])
+	if err != nil {
+		return Entry{}, ErrNotLogLine
+	}
+	e.Level = lvl
+	e.Message = strings.TrimRight(fields[1], " ")
+	if u, ok := unquotePretty(e.Message); ok {
+		e.Message = u
+	}
+	for i, field := range fields[2:] {
+		field = strings.TrimRight(field, " ")
+		if key, val, ok := strings.Cut(field, "="); ok && key != "" && !strings.ContainsAny(key, " \"") {
+			e.Attrs = append(e.Attrs, slog.Attr{Key: key, Value: prettyValue(val)})
+			continue
+		}
+		e.Attrs = append(e.Attrs, slog.Attr{Key: strconv.Itoa(i), Value: prettyValue(field)})
+	}
+	return e, nil
+}
+
+// splitPretty splits the pretty line by the separator, except the separators in the quoted fields
+// and in the quoted values of the key=value fields.
+func splitPretty(line, sep string) []string {
+	var fields []string
+	for {
+		start := 0
+		if q := quoteStart(line); q >= 0 {
+			if _, rest, ok := cutToken(line[q:], 0); ok {
+				start = len(line) - len(rest)
+			}
+		}
+		i := strings.Index(line[start:], sep)
+		if i < 0 {
+			return append(fields, line)
+		}
+		fields = append(fields, line[:start+i])
+		line = line[start+i+len(sep):]
+	}
+}
+
+// quoteStart returns the offset of the quoted string at the start of the field, or -1.
+func quoteStart(field string) int {
+	if strings.HasPrefix(field, `"`) {
+		return 0
+	}
+	if key, _, ok := strings.Cut(field, `="`); ok && key != "" && !strings.ContainsAny(key, " \"") {
+		return len(key) + 1
+	}
+	return -1
+}
+
+// prettyValue returns the value of the pretty field, the quoted strings are unquoted.
+func prettyValue(s string) slog.Value {
+	s = strings.TrimRight(s, " ")
+	if u, ok := unquotePretty(s); ok {
+		return slog.StringValue(u)
+	}
+	return inferValue(s)
+}
+
+// unquotePretty unquotes the string quoted by the pretty Handler.
+func unquotePretty(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", false
+	}
+	u, err := unquote(s)
+	return u, err == nil
+}
+
+// stripEscapes returns b without the ANSI escape sequences.
+func stripEscapes(b []byte) []byte {
+	if bytes.IndexByte(b, '\x1b') < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		if n := escapeLen(b[i:]); n > 0 {
+			i += n
+			continue
+		}
+		out = append(out, b[i])
+		i++
+	}
+	return out
+}
+
+// Scanner reads the entries from the lines of an io.Reader.
+//
+// Usage:
+//
+//	sc := NewScanner(r, nil)
+//	for sc.Scan() {
+//		entry, err := sc.Entry()
+//	}
+//	if err := sc.Err(); err != nil {
+//	}
+type Scanner struct {
+	p     *Parser
+	sc    *bufio.Scanner
+	entry Entry
+	err   error
+}
+
+// maxLineSize is the maximum size of a line read by Scanner.
+const maxLineSize = 1 << 20
+
+// NewScanner returns a new Scanner to read from r.
+// If the parser is nil, NewParser is used.
+func NewScanner(r io.Reader, p *Parser) *Scanner {
+	if p == nil {
+		p = NewParser()
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	return &Scanner{p: p, sc: sc}
+}
+
+// Scan advances the Scanner to the next line, which is then available through the Entry and Line methods.
+// It returns false when the scan stops, either by reaching the end of the input or an error.
+func (s *Scanner) Scan() bool {
+	if !s.sc.Scan() {
+		return false
+	}
+	s.entry, s.err = s.p.Parse(s.sc.Bytes())
+	return true
+}
+
+// Entry returns the entry of the current line, or the error if the line can't be parsed.
+func (s *Scanner) Entry() (Entry, error) {
+	return s.entry, s.err
+}
+
+// Line returns the raw current line without the newline.
+// The underlying array may be overwritten by a subsequent call to Scan.
+func (s *Scanner) Line() []byte {
+	return s.sc.Bytes()
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
@@ -0,0 +1,155 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	ctx := context.Background()
+	tm := time.Date(2024, 7, 26, 5, 26, 24, 0, time.Local)
+
+	attrs := []slog.Attr{slog.Int("code", 404), slog.String("service", "Payment Service"), slog.Float64("usage", 85.7), slog.Bool("ok", false)}
+	wantAttrs := fmt.Sprint(attrs)
+
+	// Test cases
+	cases := []struct {
+		name    string
+		handler func(w *bytes.Buffer) slog.Handler
+		attrs   string
+	}{
+		{
+			name:    "Struct",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewStructHandler(w, &slog.HandlerOptions{Level: LevelFx}) },
+			attrs:   wantAttrs,
+		},
+		{
+			name:    "Logfmt",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewLogfmtHandler(w, nil) },
+			attrs:   wantAttrs,
+		},
+		{
+			name:    "JSON",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewJSONHandler(w, nil) },
+			attrs:   wantAttrs,
+		},
+		{
+			name:    "Pretty",
+			handler: func(w *bytes.Buffer) slog.Handler { return NewPrettyHandler(w, nil) },
+			attrs:   "[0=404 1=Payment Service 2=85.7 3=false]",
+		},
+		{
+			name: "Pretty with keys",
+			handler: func(w *bytes.Buffer) slog.Handler {
+				return NewHandlerBuilder().WithPretty().WithPrettyKeys().WithColumns().WithWriter(w).Build()
+			},
+			attrs: wantAttrs,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := test.handler(&buf)
+			for _, lvl := range []slog.Level{LevelFx, LevelFxError, LevelInfo, LevelError + 2} {
+				r := slog.NewRecord(tm, lvl, "High memory usage", 0)
+				r.AddAttrs(attrs...)
+				if err := h.Handle(ctx, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sc := NewScanner(&buf, nil)
+			var levels []slog.Level
+			for sc.Scan() {
+				e, err := sc.Entry()
+				if err != nil {
+					t.Fatalf("%v in %q", err, sc.Line())
+				}
+				if !e.Time.Equal(tm) {
+					t.Errorf("\ngot  %s\nwant %s", e.Time, tm)
+				}
+				if e.Message != "High memory usage" {
+					t.Errorf("\ngot  %s\nwant %s", e.Message, "High memory usage")
+				}
+				if got := fmt.Sprint(e.Attrs); got != test.attrs {
+					t.Errorf("\ngot  %s\nwant %s", got, test.attrs)
+				}
+				levels = append(levels, e.Level)
+			}
+			if err := sc.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fmt.Sprint(levels), fmt.Sprint([]slog.Level{LevelFx, LevelFxError, LevelInfo, LevelError + 2}); got != want {
+				t.Errorf("\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseSeparatorInValue(t *testing.T) {
+	for _, line := range []string{
+		`time=2024-07-26T05:26:24Z level=INFO msg="a | b" k="x | y"`,
+		`level=INFO msg="a | b" k="x | y"`,
+		`{"time":"2024-07-26T05:26:24Z","level":"INFO","msg":"a | b","k":"x | y"}`,
+	} {
+		e, err := Parse([]byte(line))
+		if err != nil {
+			t.Fatalf("%v in %q", err, line)
+		}
+		if got, want := e.Message+" "+fmt.Sprint(e.Attrs), "a | b [k=x | y]"; got != want {
+			t.Errorf("\ngot  %s\nwant %s", got, want)
+		}
+	}
+}
+
+func TestParsePrettySeparator(t *testing.T) {
+	cases := []struct {
+		name    string
+		builder *HandlerBuilder
+		want    string
+	}{
+		{"Pretty", NewHandlerBuilder().WithPretty(), `a | b [0=x | y 1="q" 2=1]`},
+		{"Pretty with keys", NewHandlerBuilder().WithPretty().WithPrettyKeys().WithColorMode(ColorAlways), `a | b [k=x | y s="q" n=1]`},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(test.builder.WithWriter(&buf).Build())
+			logger.Info("a | b", "k", "x | y", "s", `"q"`, "n", 1)
+
+			e, err := Parse(buf.Bytes())
+			if err != nil {
+				t.Fatalf("%v in %q", err, buf.String())
+			}
+			if got := e.Message + " " + fmt.Sprint(e.Attrs); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseNotLogLine(t *testing.T) {
+	for _, line := range []string{"", "panic: runtime error", "{\"a\":1}", "a=1 b=2", "    stacktrace:", "x | y | z"} {
+		if _, err := Parse([]byte(line)); err != ErrNotLogLine {
+			t.Errorf("\ngot  %v\nwant %v for %q", err, ErrNotLogLine, line)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	line := `{"time":"2024-07-26T05:26:24Z","level":"FX","msg":"provided","stacktrace":{"0":"main.go:12","1":"fx.go:7"},"n":[1,2]}`
+	e, err := Parse([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[stacktrace=[0=main.go:12 1=fx.go:7] n=[1 2]]"
+	if got := fmt.Sprint(e.Attrs); got != want || e.Level != LevelFx || !strings.HasPrefix(e.Time.String(), "2024-07-26 05:26:24") {
+		t.Errorf("\ngot  %s %s %s\nwant %s", got, e.Level, e.Time, want)
+	}
+}
@@ -0,0 +1,300 @@
+package otris
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions are the options of the RotatingFile, the zero values disable the limits.
+type RotateOptions struct {
+	// MaxSize is the maximum size of the file in bytes before it's rotated.
+	MaxSize int64
+	// Interval is the interval of the rotation, e.g. 24 * time.Hour rotates the file at midnight UTC.
+	Interval time.Duration
+	// MaxBackups is the maximum number of the rotated files to keep.
+	MaxBackups int
+	// MaxAge is the maximum age of the rotated files to keep.
+	MaxAge time.Duration
+	// Compress compresses the rotated files with gzip in the background.
+	Compress bool
+	// ReopenOnSIGHUP reopens the file on SIGHUP, so it can be rotated by logrotate. It works only on unix.
+	ReopenOnSIGHUP bool
+}
+
+// backupTimeLayout is the layout of the time in the names of the rotated files.
+const backupTimeLayout = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.Writer to the file that is rotated by size and time.
+// The rotated files are renamed to the name with the time of the rotation, e.g. "app-2024-05-01T12-00-00.000.log",
+// and are optionally compressed and pruned in the background.
+// It is safe for concurrent use, the Handler and its clones write to it under the shared mutex.
+//
+// Usage:
+//
+//	file, err := NewRotatingFile("app.log", RotateOptions{MaxSize: 100 << 20, MaxBackups: 7, Compress: true})
+//	if err != nil {
+//		return err
+//	}
+//	handler := NewHandlerBuilder().WithJSON().WithWriter(file).Build()
+//	defer handler.Close()
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu     sync.Mutex
+	f      *os.File // nil if the rotation or reopening failed, the file is opened again by Write
+	size   int64
+	next   time.Time // time of the next rotation by interval
+	closed bool
+
+	millMu sync.Mutex     // serializes the compression and the pruning
+	wg     sync.WaitGroup // background compression and pruning
+	stop   func()         // stops reopening on SIGHUP
+}
+
+// ErrFileClosed is returned by the writes to the closed RotatingFile.
+var ErrFileClosed = errors.New("otris: file is closed")
+
+// NewRotatingFile opens the file in the append mode, creating it and its directory if needed.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.stop = func() {}
+	if opts.ReopenOnSIGHUP {
+		r.stop = notifyReopen(r)
+	}
+	return r, nil
+}
+
+// open opens the file, mu must be locked.
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	if r.opts.Interval > 0 {
+		r.next = time.Now().Truncate(r.opts.Interval).Add(r.opts.Interval)
+	}
+	return nil
+}
+
+// Write writes the record to the file, the file is rotated before the write if it exceeds the limits.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, ErrFileClosed
+	}
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.size > 0 && ((r.opts.MaxSize > 0 && r.size+int64(len(p)) > r.opts.MaxSize) ||
+		(r.opts.Interval > 0 && !time.Now().Before(r.next))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrFileClosed
+	}
+	return r.rotate()
+}
+
+// rotate renames the file to the backup name and opens the new file, mu must be locked.
+// If it fails, the file is opened again by the next Write.
+func (r *RotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, r.backupName(time.Now())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.mill()
+	}()
+	return nil
+}
+
+// Reopen closes and reopens the file, e.g. after it's moved by logrotate.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrFileClosed
+	}
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// closeFile closes the file if it's open, mu must be locked.
+func (r *RotatingFile) closeFile() error {
+	if r.f == nil {
+		return nil
+	}
+	f := r.f
+	r.f = nil
+	return f.Close()
+}
+
+// Sync commits the file to the stable storage.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.f == nil {
+		return nil
+	}
+	return r.f.Sync()
+}
+
+// Close closes the file and waits for the background compression and pruning.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	err := r.closeFile()
+	r.mu.Unlock()
+
+	r.stop()
+	r.wg.Wait()
+	return err
+}
+
+// backupName returns the unused name of the rotated file.
+// The name that can't be checked is returned too, e.g. if the directory is removed, so the rename fails.
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := r.nameParts()
+	for {
+		name := filepath.Join(dir, prefix+t.UTC().Format(backupTimeLayout)+ext)
+		if _, err := os.Stat(name); err != nil {
+			if _, err := os.Stat(name + ".gz"); err != nil {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// nameParts returns the directory, the prefix and the extension of the names of the rotated files.
+func (r *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir, name := filepath.Split(r.path)
+	ext = filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// backup is a rotated file.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files, the newest first.
+func (r *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := r.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts, ok := strings.CutSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(backupTimeLayout, strings.TrimPrefix(ts, prefix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+	slices.SortFunc(backups, func(a, b backup) int { return b.time.Compare(a.time) })
+	return backups, nil
+}
+
+// mill compresses and prunes the rotated files.
+func (r *RotatingFile) mill() {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+	backups, err := r.backups()
+	if err != nil {
+		return
+	}
+	for i, b := range backups {
+		expired := r.opts.MaxAge > 0 && time.Since(b.time) > r.opts.MaxAge
+		if (r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups) || expired {
+			os.Remove(b.path)
+			continue
+		}
+		if r.opts.Compress && !strings.HasSuffix(b.path, ".gz") {
+			compressFile(b.path)
+		}
+	}
+}
+
+// compressFile compresses the file to the file with the ".gz" suffix and removes it.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	err = errors.Join(err, zw.Close(), dst.Close())
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
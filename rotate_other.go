@@ -0,0 +1,8 @@
+//go:build !unix
+
+package otris
+
+// notifyReopen does nothing, there is no SIGHUP.
+func notifyReopen(r *RotatingFile) (stop func()) {
+	return func() {}
+}
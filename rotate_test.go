@@ -0,0 +1,158 @@
+package otris
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	file, err := NewRotatingFile(path, RotateOptions{MaxSize: 100, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandlerBuilder().WithWriter(file).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).Build()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(logger *slog.Logger) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				logger.Info("message", "j", j)
+			}
+		}(slog.New(h).WithGroup("g").With("i", i))
+	}
+	wg.Wait()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := file.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("%d backups, want 2", len(backups))
+	}
+	lines := 0
+	for _, b := range append(backups, backup{path: path}) {
+		data := readLogFile(t, b.path)
+		if len(data) > 100 {
+			t.Errorf("%s has %d bytes, want at most 100", filepath.Base(b.path), len(data))
+		}
+		for _, line := range strings.SplitAfter(data, "\n") {
+			if line != "" && !strings.HasPrefix(line, "level=INFO msg=message g.i=") {
+				t.Errorf("invalid line %q in %s", line, filepath.Base(b.path))
+			}
+			if strings.HasSuffix(line, "\n") {
+				lines++
+			}
+		}
+	}
+	if lines == 0 || lines >= 40 {
+		t.Errorf("%d lines are kept, the oldest backups must be pruned", lines)
+	}
+	if _, err := file.Write([]byte("x\n")); err != ErrFileClosed {
+		t.Errorf("got %v, want ErrFileClosed", err)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "app.log")
+	file, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first\n"))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("second\n"))
+	if err := file.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("third\n"))
+
+	if got, want := readLogFile(t, path+".1"), "first\nsecond\n"; got != want {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+	if got, want := readLogFile(t, path), "third\n"; got != want {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestRotatingFileOpenError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	file, err := NewRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first\n"))
+	// The directory is replaced by a file, so the rotation fails after the file is closed.
+	if err := os.Rename(dir, dir+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Rotate(); err == nil {
+		t.Fatal("\ngot  nil\nwant error")
+	}
+	if _, err := file.Write([]byte("lost\n")); err == nil {
+		t.Error("\ngot  nil\nwant error")
+	}
+
+	// The file is opened again by the next Write when the directory is restored.
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readLogFile(t, filepath.Join(dir+".old", "app.log")), "first\n"; got != want {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+	if got, want := readLogFile(t, path), "second\n"; got != want {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// readLogFile reads the log file, the ".gz" files are decompressed.
+func readLogFile(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package otris
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen reopens the file on SIGHUP until the returned function is called.
+func notifyReopen(r *RotatingFile) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-c:
+				_ = r.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package otris
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewRotatingFile(path, RotateOptions{ReopenOnSIGHUP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.Write([]byte("first\n"))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the file isn't reopened on SIGHUP")
+		}
+	}
+	file.Write([]byte("second\n"))
+	if got, want := readLogFile(t, path), "second\n"; got != want {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,393 @@
+package otris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// RouteRecord is the record seen by the rules of the Router, it's not formatted.
+type RouteRecord struct {
+	Level   slog.Level
+	Message string
+	// Module is the module of the logger or the record, see ModuleLevels.
+	Module string
+
+	record *slog.Record
+	prefix string      // groups of the logger, joined and ended with '.'
+	attrs  []slog.Attr // attributes of the logger, with the group prefixes in the keys
+	ctx    []slog.Attr // attributes of the context from WithContextAttrs
+}
+
+// Attr returns the value of the attribute of the record, the context or the logger.
+// The key includes the groups, e.g. "request.id".
+func (r *RouteRecord) Attr(key string) (v slog.Value, ok bool) {
+	if rest, found := strings.CutPrefix(key, r.prefix); found {
+		r.record.Attrs(func(a slog.Attr) bool {
+			v, ok = lookupAttr(a, rest)
+			return !ok
+		})
+		if !ok {
+			v, ok = lookupAttrs(r.ctx, rest)
+		}
+	}
+	if !ok {
+		v, ok = lookupAttrs(r.attrs, key)
+	}
+	return v, ok
+}
+
+func lookupAttrs(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for _, a := range attrs {
+		if v, ok := lookupAttr(a, key); ok {
+			return v, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// lookupAttr returns the value of the attribute or of its group attribute by the dotted key.
+func lookupAttr(a slog.Attr, key string) (slog.Value, bool) {
+	if a.Key == key {
+		return a.Value.Resolve(), true
+	}
+	rest, ok := strings.CutPrefix(key, a.Key+string(keyComponentSep))
+	if !ok && a.Key != "" {
+		return slog.Value{}, false
+	}
+	if a.Key == "" {
+		rest = key
+	}
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return slog.Value{}, false
+	}
+	return lookupAttrs(v.Group(), rest)
+}
+
+// Rule reports whether the record is routed to the handler of the Route.
+type Rule func(r *RouteRecord) bool
+
+// LevelAtLeast matches the records with the level greater than or equal to the level.
+func LevelAtLeast(level slog.Leveler) Rule {
+	return func(r *RouteRecord) bool { return r.Level >= level.Level() }
+}
+
+// LevelBelow matches the records with the level less than the level.
+func LevelBelow(level slog.Leveler) Rule {
+	return func(r *RouteRecord) bool { return r.Level < level.Level() }
+}
+
+// Module matches the records of the module and its submodules, e.g. "db" matches "db" and "db.sql".
+func Module(name string) Rule {
+	return func(r *RouteRecord) bool {
+		rest, ok := strings.CutPrefix(r.Module, name)
+		return ok && (rest == "" || rest[0] == keyComponentSep)
+	}
+}
+
+// AttrEquals matches the records with the attribute equal to the value, see RouteRecord.Attr.
+func AttrEquals(key string, value any) Rule {
+	want := slog.AnyValue(value)
+	return func(r *RouteRecord) bool {
+		v, ok := r.Attr(key)
+		return ok && v.Equal(want)
+	}
+}
+
+// FxEvents matches the records of the fx events, logged with LevelFx and LevelFxError by SlogLogger.
+func FxEvents(r *RouteRecord) bool {
+	return r.Level == LevelFx || r.Level == LevelFxError
+}
+
+// And matches the records matched by all rules.
+func And(rules ...Rule) Rule {
+	return func(r *RouteRecord) bool {
+		for _, rule := range rules {
+			if !rule(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches the records matched by any rule.
+func Or(rules ...Rule) Rule {
+	return func(r *RouteRecord) bool {
+		for _, rule := range rules {
+			if rule(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches the records not matched by the rule.
+func Not(rule Rule) Rule {
+	return func(r *RouteRecord) bool { return !rule(r) }
+}
+
+// ParseRule parses the rule expression, so the routes can be read from config files.
+// The expression is the conditions joined with "&&" and "||", "&&" binds tighter:
+//
+//	fx                  the fx events
+//	level>=ERROR        the level compared with =, !=, <, <=, > or >=, parsed with ParseLevel
+//	module=db           the module and its submodules, or != for the other modules
+//	request.method=GET  the string value of the attribute, or != for the other values
+//
+// For example, "level>=ERROR || module=db && level>=WARN".
+func ParseRule(expr string) (Rule, error) {
+	var alts []Rule
+	for _, conj := range strings.Split(expr, "||") {
+		var all []Rule
+		for _, cond := range strings.Split(conj, "&&") {
+			rule, err := parseCondition(strings.TrimSpace(cond))
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, rule)
+		}
+		alts = append(alts, And(all...))
+	}
+	return Or(alts...), nil
+}
+
+// ruleOps are the operators of the conditions, the longer first.
+var ruleOps = []string{">=", "<=", "!=", "=", "<", ">"}
+
+func parseCondition(cond string) (Rule, error) {
+	if strings.EqualFold(cond, "fx") {
+		return FxEvents, nil
+	}
+	i, op := -1, ""
+	for _, o := range ruleOps {
+		if j := strings.Index(cond, o); j > 0 && (i < 0 || j < i) {
+			i, op = j, o
+		}
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("otris: invalid condition %q", cond)
+	}
+	key, value := strings.TrimSpace(cond[:i]), strings.TrimSpace(cond[i+len(op):])
+	if key == "" || value == "" {
+		return nil, fmt.Errorf("otris: invalid condition %q", cond)
+	}
+
+	switch key {
+	case "level":
+		lvl, err := ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(r *RouteRecord) bool { return compareLevels(r.Level, op, lvl) }, nil
+	case ModuleKey:
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("otris: invalid operator %q for the module in %q", op, cond)
+		}
+		rule := Module(value)
+		if op == "!=" {
+			rule = Not(rule)
+		}
+		return rule, nil
+	default:
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("otris: invalid operator %q for the attribute in %q", op, cond)
+		}
+		return func(r *RouteRecord) bool {
+			v, ok := r.Attr(key)
+			return (ok && v.String() == value) == (op == "=")
+		}, nil
+	}
+}
+
+func compareLevels(a slog.Level, op string, b slog.Level) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// Route routes the records matched by the rule to the handler.
+type Route struct {
+	Rule    Rule
+	Handler slog.Handler
+}
+
+// Router dispatches each record to the handlers of all routes whose rules match it,
+// and to the fallback handler if no rule matches it. The rules are evaluated before formatting.
+//
+// Usage:
+//
+//	router := NewRouter(console,
+//		Route{Rule: LevelAtLeast(LevelError), Handler: stderrJSON},
+//		Route{Rule: Module("db"), Handler: dbFile},
+//		Route{Rule: FxEvents, Handler: fxFile},
+//	)
+//	logger := slog.New(router)
+type Router struct {
+	routes   []Route
+	fallback slog.Handler
+	module   string
+	prefix   string
+	attrs    []slog.Attr
+}
+
+var _ Lifecycle = (*Router)(nil)
+
+// NewRouter creates a new Router, the fallback handler can be nil.
+func NewRouter(fallback slog.Handler, routes ...Route) *Router {
+	return &Router{routes: routes, fallback: fallback}
+}
+
+// Enabled reports whether any handler handles records at the given level.
+func (r *Router) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, route := range r.routes {
+		if route.Handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return r.fallback != nil && r.fallback.Enabled(ctx, level)
+}
+
+// Handle dispatches the record to the handlers of the matching routes, or to the fallback handler.
+// It returns the joined errors of the handlers.
+func (r *Router) Handle(ctx context.Context, record slog.Record) error {
+	rr := RouteRecord{
+		Level:   record.Level,
+		Message: record.Message,
+		Module:  r.module,
+		record:  &record,
+		prefix:  r.prefix,
+		attrs:   r.attrs,
+		ctx:     ContextAttrs(ctx),
+	}
+	if rr.Module == "" {
+		rr.Module = recordModule(record)
+	}
+	if rr.Module == "" {
+		for _, a := range rr.ctx {
+			if m, ok := moduleOf(a); ok {
+				rr.Module = m
+				break
+			}
+		}
+	}
+
+	var errs []error
+	matched := false
+	for _, route := range r.routes {
+		if !route.Rule(&rr) {
+			continue
+		}
+		matched = true
+		if route.Handler.Enabled(ctx, record.Level) {
+			errs = append(errs, route.Handler.Handle(ctx, record.Clone()))
+		}
+	}
+	if !matched && r.fallback != nil && r.fallback.Enabled(ctx, record.Level) {
+		errs = append(errs, r.fallback.Handle(ctx, record))
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a new Router with the attributes added to all handlers.
+func (r *Router) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return r
+	}
+	r2 := r.clone()
+	for i := range r2.routes {
+		r2.routes[i].Handler = r2.routes[i].Handler.WithAttrs(attrs)
+	}
+	if r2.fallback != nil {
+		r2.fallback = r2.fallback.WithAttrs(attrs)
+	}
+	r2.attrs = slices.Clip(r2.attrs)
+	for _, a := range attrs {
+		if module, ok := moduleOf(a); ok {
+			r2.module = module
+		}
+		a.Key = r.prefix + a.Key
+		r2.attrs = append(r2.attrs, a)
+	}
+	return r2
+}
+
+// WithGroup returns a new Router with the group opened in all handlers.
+func (r *Router) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	r2 := r.clone()
+	for i := range r2.routes {
+		r2.routes[i].Handler = r2.routes[i].Handler.WithGroup(name)
+	}
+	if r2.fallback != nil {
+		r2.fallback = r2.fallback.WithGroup(name)
+	}
+	r2.module = joinModule(r2.module, name)
+	r2.prefix += name + string(keyComponentSep)
+	return r2
+}
+
+func (r *Router) clone() *Router {
+	return &Router{
+		routes:   slices.Clone(r.routes),
+		fallback: r.fallback,
+		module:   r.module,
+		prefix:   r.prefix,
+		attrs:    r.attrs,
+	}
+}
+
+// Flush flushes all handlers that implement Lifecycle.
+func (r *Router) Flush() error {
+	return r.each(Lifecycle.Flush)
+}
+
+// Sync syncs all handlers that implement Lifecycle.
+func (r *Router) Sync() error {
+	return r.each(Lifecycle.Sync)
+}
+
+// Close closes all handlers that implement Lifecycle.
+func (r *Router) Close() error {
+	return r.each(Lifecycle.Close)
+}
+
+// each calls f once for every handler, the same handler can be used by several routes.
+// Only the pointers are compared, the other handlers are distinct even if they're equal,
+// and their comparison could panic on the non-comparable values in their interface fields.
+func (r *Router) each(f func(Lifecycle) error) error {
+	handlers := make([]slog.Handler, 0, len(r.routes)+1)
+	for _, h := range append(r.handlers(), r.fallback) {
+		if h != nil && (reflect.ValueOf(h).Kind() != reflect.Pointer || !slices.Contains(handlers, h)) {
+			handlers = append(handlers, h)
+		}
+	}
+	return (&MultiHandler{handlers: handlers}).each(f)
+}
+
+func (r *Router) handlers() []slog.Handler {
+	handlers := make([]slog.Handler, len(r.routes))
+	for i, route := range r.routes {
+		handlers[i] = route.Handler
+	}
+	return handlers
+}
@@ -0,0 +1,131 @@
+package otris
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	var console, errs, db, fx bytes.Buffer
+	opts := &slog.HandlerOptions{Level: LevelFx, ReplaceAttr: removeTime}
+	newHandler := func(w *bytes.Buffer) *Handler {
+		return NewHandlerBuilder().WithWriter(w).WithOptions(opts).Build()
+	}
+	dbRule, err := ParseRule("module=db && level>=INFO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(newHandler(&console),
+		Route{Rule: LevelAtLeast(LevelError), Handler: NewHandlerBuilder().WithJSON().WithWriter(&errs).WithOptions(opts).Build()},
+		Route{Rule: dbRule, Handler: newHandler(&db)},
+		Route{Rule: FxEvents, Handler: newHandler(&fx)},
+	)
+	logger := slog.New(router)
+
+	logger.Info("started")
+	logger.Log(context.Background(), LevelFx, "provided")
+	logger.With(ModuleKey, "db").WithGroup("sql").Info("query", "rows", 2)
+	logger.WithGroup("db").Debug("hidden by the rule")
+	logger.WithGroup("db").Error("failed")
+
+	cases := []struct {
+		name string
+		got  *bytes.Buffer
+		want string
+	}{
+		{name: "Fallback", got: &console, want: "level=INFO msg=started\nlevel=DEBUG msg=\"hidden by the rule\"\n"},
+		{name: "Errors", got: &errs, want: `{"level":"ERROR","msg":"failed"}` + "\n"},
+		{name: "Module", got: &db, want: "level=INFO msg=query module=db sql.rows=2\nlevel=ERROR msg=failed\n"},
+		{name: "Fx", got: &fx, want: "level=FX msg=provided\n"},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got.String() != test.want {
+				t.Errorf("\ngot  %s\nwant %s", test.got.String(), test.want)
+			}
+		})
+	}
+}
+
+// lifecycleCounter is a comparable handler, comparing it panics if meta holds a non-comparable value.
+type lifecycleCounter struct {
+	slog.Handler
+	closed *int
+	meta   any
+}
+
+func (h lifecycleCounter) Flush() error { return nil }
+func (h lifecycleCounter) Sync() error  { return nil }
+func (h lifecycleCounter) Close() error {
+	*h.closed++
+	return nil
+}
+
+func TestRouterClose(t *testing.T) {
+	closed, pointerClosed := 0, 0
+	value := lifecycleCounter{Handler: slog.Default().Handler(), closed: &closed, meta: map[string]int{}}
+	pointer := &lifecycleCounter{Handler: slog.Default().Handler(), closed: &pointerClosed, meta: map[string]int{}}
+	router := NewRouter(pointer,
+		Route{Rule: FxEvents, Handler: value},
+		Route{Rule: LevelAtLeast(LevelError), Handler: value},
+		Route{Rule: LevelAtLeast(LevelWarning), Handler: pointer},
+	)
+	if err := router.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The handlers that aren't pointers are closed by every route, the pointer is closed once.
+	if closed != 2 || pointerClosed != 1 {
+		t.Errorf("closed %d and %d times, want 2 and 1", closed, pointerClosed)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	ctx := WithContextAttrs(context.Background(), slog.String("tenant", "acme"))
+	record := slog.NewRecord(time.Now(), LevelWarning, "message", 0)
+	record.AddAttrs(slog.Group("request", slog.String("method", "GET")), slog.Int("status", 404))
+
+	// Test cases
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "level>=WARN", want: true},
+		{expr: "level>WARN", want: false},
+		{expr: "level!=ERROR && level=warn", want: true},
+		{expr: "fx || level<DEBUG", want: false},
+		{expr: "g.request.method=GET", want: true},
+		{expr: "g.request.method!=GET || g.status=404", want: true},
+		{expr: "g.tenant=acme", want: true},
+		{expr: "user=bob", want: true},
+		{expr: "module=db", want: false},
+		{expr: "module!=db && module=g", want: true},
+	}
+
+	for _, test := range cases {
+		t.Run(test.expr, func(t *testing.T) {
+			rule, err := ParseRule(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var matched bool
+			router := NewRouter(nil, Route{Rule: func(r *RouteRecord) bool {
+				matched = rule(r)
+				return matched
+			}, Handler: NewJSONHandler(&bytes.Buffer{}, nil)})
+			h := router.WithAttrs([]slog.Attr{slog.String("user", "bob")}).WithGroup("g")
+			h.Handle(ctx, record)
+			if matched != test.want {
+				t.Errorf("got %t, want %t", matched, test.want)
+			}
+		})
+	}
+
+	for _, expr := range []string{"", "level", "level>=LOUD", "module>db", "status>400", "=x"} {
+		if _, err := ParseRule(expr); err == nil {
+			t.Errorf("ParseRule(%q) doesn't return an error", expr)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package otris
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingOptions are the options of the sampling and the rate limiting of the records.
+//
+// In every Interval, the first First records with the same level and message are handled,
+// and then every Thereafter-th of them. The handled records are also limited by the token bucket
+// of Rate records per second with the size Burst. The numbers of the dropped records are logged
+// as a warning record by a timer, SummaryInterval after the first of them, so the summary is written
+// even if nothing is logged after.
+//
+// The records are counted in 4096 counters by the hash of the level and the message, the keys with
+// the colliding hashes share the counter, so their records are sampled together.
+type SamplingOptions struct {
+	// Interval is the interval of the sampling counters, time.Second if zero.
+	Interval time.Duration
+	// First is the number of the records handled in every interval, the sampling is disabled if it's zero.
+	First int
+	// Thereafter is the sampling rate after the first records, 0 drops all of them.
+	Thereafter int
+	// Rate is the number of the records per second, the rate limiting is disabled if it's zero.
+	Rate float64
+	// Burst is the maximum number of the records handled at once by the rate limiter, 1 if zero.
+	Burst int
+	// SummaryInterval is the interval of the summary records, DefaultDropReportInterval if zero.
+	SummaryInterval time.Duration
+}
+
+// Keys of the numbers of the dropped records in the summary records.
+const (
+	SampledKey     = "sampled"
+	RateLimitedKey = "rate_limited"
+)
+
+// samplerCounters is the number of the sampling counters, the keys are hashed into them.
+// The colliding keys share the counter, it's cheaper than the exact keys without the allocations.
+const samplerCounters = 4096
+
+// sampler samples and rate limits the records, it's shared by the Handler and its clones.
+// The hot path is lock-free.
+type sampler struct {
+	opts     SamplingOptions
+	counters [samplerCounters]sampleCounter
+
+	tat      atomic.Int64 // theoretical arrival time of the rate limiter, in nanoseconds
+	emission int64        // nanoseconds per record
+	burst    int64        // nanoseconds of the burst
+
+	sampled     atomic.Uint64
+	rateLimited atomic.Uint64
+	armed       atomic.Bool // the summary timer is started
+	report      func(sampled, rateLimited uint64)
+}
+
+// sampleCounter counts the records of a key in the current interval.
+type sampleCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+func newSampler(opts SamplingOptions, report func(sampled, rateLimited uint64)) *sampler {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = 1
+	}
+	if opts.SummaryInterval <= 0 {
+		opts.SummaryInterval = DefaultDropReportInterval
+	}
+	s := &sampler{opts: opts, report: report}
+	if opts.Rate > 0 {
+		s.emission = int64(float64(time.Second) / opts.Rate)
+		s.burst = s.emission * int64(opts.Burst-1)
+	}
+	return s
+}
+
+// allow reports whether the record is handled, it counts the dropped records.
+func (s *sampler) allow(level slog.Level, msg string, t time.Time) bool {
+	now := t.UnixNano()
+	if s.opts.First > 0 {
+		n := s.counters[sampleKey(level, msg)].inc(now, int64(s.opts.Interval))
+		if first := uint64(s.opts.First); n > first && (s.opts.Thereafter <= 0 || (n-first)%uint64(s.opts.Thereafter) != 0) {
+			s.sampled.Add(1)
+			s.arm()
+			return false
+		}
+	}
+	if s.emission > 0 && !s.take(now) {
+		s.rateLimited.Add(1)
+		s.arm()
+		return false
+	}
+	return true
+}
+
+// take takes a token from the bucket with the generic cell rate algorithm.
+func (s *sampler) take(now int64) bool {
+	for {
+		tat := s.tat.Load()
+		next := max(tat, now)
+		if next-now > s.burst {
+			return false
+		}
+		if s.tat.CompareAndSwap(tat, next+s.emission) {
+			return true
+		}
+	}
+}
+
+// inc increments the counter, the counter is reset if the interval is over.
+func (c *sampleCounter) inc(now, interval int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+interval) {
+		return c.n.Add(1)
+	}
+	return 1
+}
+
+// sampleKey returns the index of the counter of the level and the message, the FNV-1a hash is inlined
+// to not allocate on the hot path.
+func sampleKey(level slog.Level, msg string) uint32 {
+	const prime = 16777619
+	h := uint32(2166136261)
+	h = (h ^ uint32(byte(level))) * prime
+	for i := 0; i < len(msg); i++ {
+		h = (h ^ uint32(msg[i])) * prime
+	}
+	return h % samplerCounters
+}
+
+// arm starts the summary timer if it isn't started, so the timer runs only while the records are dropped.
+// The timer is disarmed before the summary, so the records dropped after it start the next timer.
+func (s *sampler) arm() {
+	if s.report == nil || s.armed.Load() || !s.armed.CompareAndSwap(false, true) {
+		return
+	}
+	time.AfterFunc(s.opts.SummaryInterval, func() {
+		s.armed.Store(false)
+		s.summarize()
+	})
+}
+
+// summarize reports the records dropped since the previous summary.
+func (s *sampler) summarize() {
+	sampled, rateLimited := s.sampled.Swap(0), s.rateLimited.Swap(0)
+	if (sampled > 0 || rateLimited > 0) && s.report != nil {
+		s.report(sampled, rateLimited)
+	}
+}
+
+// reportSampled logs the summary record about the records dropped by the sampler.
+func (h *Handler) reportSampled(sampled, rateLimited uint64) {
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "otris: log records dropped", 0)
+	r.AddAttrs(slog.Uint64(SampledKey, sampled), slog.Uint64(RateLimitedKey, rateLimited))
+	_ = h.handle(context.Background(), r, nil)
+}
+
+// flushSampler reports the records dropped by the sampler since the last summary.
+func (h *Handler) flushSampler() {
+	if h.sampler != nil {
+		h.sampler.summarize()
+	}
+}
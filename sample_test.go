@@ -0,0 +1,166 @@
+package otris
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		opts  SamplingOptions
+		times []time.Duration // offsets of the records from start
+		want  string          // allowed records
+	}{
+		{
+			name:  "first",
+			opts:  SamplingOptions{First: 2},
+			times: []time.Duration{0, 1, 2, 3},
+			want:  "1100",
+		},
+		{
+			name:  "thereafter",
+			opts:  SamplingOptions{First: 2, Thereafter: 3},
+			times: []time.Duration{0, 1, 2, 3, 4, 5, 6, 7},
+			want:  "11001001",
+		},
+		{
+			name:  "interval",
+			opts:  SamplingOptions{First: 1, Interval: time.Second},
+			times: []time.Duration{0, time.Millisecond, time.Second, time.Second + time.Millisecond},
+			want:  "1010",
+		},
+		{
+			name:  "rate",
+			opts:  SamplingOptions{Rate: 10},
+			times: []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond},
+			want:  "1010",
+		},
+		{
+			name:  "burst",
+			opts:  SamplingOptions{Rate: 10, Burst: 3},
+			times: []time.Duration{0, 0, 0, 0, 100 * time.Millisecond, 100 * time.Millisecond},
+			want:  "111010",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newSampler(c.opts, nil)
+			var got strings.Builder
+			for _, d := range c.times {
+				if s.allow(slog.LevelInfo, "msg", start.Add(d)) {
+					got.WriteByte('1')
+				} else {
+					got.WriteByte('0')
+				}
+			}
+			if got.String() != c.want {
+				t.Errorf("\ngot  %s\nwant %s", got.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestHandlerBuilderWithSampling(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithWriter(&buf).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).
+		WithSampling(SamplingOptions{First: 2, Interval: time.Hour}).Build()
+	logger := slog.New(h)
+
+	for i := 0; i < 4; i++ {
+		logger.Info("repeated", "i", i)
+		logger.With("k", "v").Warn("repeated")
+	}
+	logger.Info("other")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "level=INFO msg=repeated i=0\n" +
+		"level=WARN msg=repeated k=v\n" +
+		"level=INFO msg=repeated i=1\n" +
+		"level=WARN msg=repeated k=v\n" +
+		"level=INFO msg=other\n" +
+		"level=WARN msg=\"otris: log records dropped\" sampled=4 rate_limited=0\n"
+	if buf.String() != want {
+		t.Errorf("\ngot  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestHandlerSamplingSummaryTimer(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandlerBuilder().WithWriter(&buf).WithOptions(&slog.HandlerOptions{ReplaceAttr: removeTime}).
+		WithSampling(SamplingOptions{First: 1, Interval: time.Hour, SummaryInterval: 10 * time.Millisecond}).Build()
+	logger := slog.New(h)
+
+	// The summary is written by the timer without the next record.
+	output := func() string {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return buf.String()
+	}
+	want := "level=INFO msg=repeated\n" +
+		"level=WARN msg=\"otris: log records dropped\" sampled=2 rate_limited=0\n"
+	for i := 0; i < 3; i++ {
+		logger.Info("repeated")
+	}
+	for deadline := time.Now().Add(5 * time.Second); output() != want && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if got := output(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+
+	// The next dropped record starts the next timer.
+	logger.Info("repeated")
+	want += "level=WARN msg=\"otris: log records dropped\" sampled=1 rate_limited=0\n"
+	for deadline := time.Now().Add(5 * time.Second); output() != want && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if got := output(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestSamplerConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var sampled uint64
+	s := newSampler(SamplingOptions{First: 100, Interval: time.Hour}, func(n, _ uint64) {
+		mu.Lock()
+		defer mu.Unlock()
+		sampled += n
+	})
+
+	var wg sync.WaitGroup
+	var allowed sync.Map
+	now := time.Now()
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			n := 0
+			for i := 0; i < 1000; i++ {
+				if s.allow(slog.LevelInfo, "msg", now) {
+					n++
+				}
+			}
+			allowed.Store(g, n)
+		}(g)
+	}
+	wg.Wait()
+	s.summarize()
+
+	total := 0
+	allowed.Range(func(_, n any) bool {
+		total += n.(int)
+		return true
+	})
+	if total != 100 || sampled != 7900 {
+		t.Errorf("\ngot  %d %d\nwant %d %d", total, sampled, 100, 7900)
+	}
+}
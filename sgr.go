@@ -0,0 +1,243 @@
+package otris
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"strconv"
+	"strings"
+)
+
+// The layout of LogColor:
+//
+//	bits  0-7   basic SGR code
+//	bits  8-11  attributes
+//	bits 12-13  foreground mode, bits 14-37 foreground value
+//	bits 38-39  background mode, bits 40-63 background value
+const (
+	basicMask = 0xff
+	fgShift   = 12
+	bgShift   = 38
+	modeBits  = 2
+	valueMask = 0xffffff
+)
+
+// Modes of the foreground and the background of LogColor.
+const (
+	modeNone  = 0
+	modeBasic = 1 // basic foreground code, used only by the background
+	mode256   = 2
+	modeRGB   = 3
+)
+
+// Attributes of LogColor, they are combined with the colors by `|`.
+const (
+	Bold LogColor = 1 << (8 + iota)
+	Dim
+	Italic
+	Underline
+)
+
+// wideColors reports whether LogColor holds the truecolor and the background colors, they need the 64-bit int.
+const wideColors = strconv.IntSize == 64
+
+// Color256 returns the foreground color of the 256-color palette.
+func Color256(n uint8) LogColor {
+	return LogColor(mode256<<fgShift | uint64(n)<<(fgShift+modeBits))
+}
+
+// RGB returns the 24-bit truecolor foreground color.
+// On the 32-bit platforms, it returns the nearest color of the 6x6x6 cube of the 256-color palette.
+func RGB(r, g, b uint8) LogColor {
+	if !wideColors {
+		cube := func(v uint8) uint8 { return uint8((int(v)*5 + 127) / 255) }
+		return Color256(16 + 36*cube(r) + 6*cube(g) + cube(b))
+	}
+	return LogColor(modeRGB<<fgShift | uint64(uint32(r)<<16|uint32(g)<<8|uint32(b))<<(fgShift+modeBits))
+}
+
+// WithBackground returns the color with the background of the foreground color of bg,
+// a basic foreground code, e.g. color.FgRed, Color256 or RGB.
+// On the 32-bit platforms, it returns the color unchanged.
+func (c LogColor) WithBackground(bg LogColor) LogColor {
+	if !wideColors {
+		return c
+	}
+	mode, value := bg.fg()
+	if mode == modeNone {
+		if code := bg & basicMask; code != 0 {
+			mode, value = modeBasic, uint32(code)
+		}
+	}
+	bits := c.bits() &^ ((1<<modeBits - 1 | valueMask<<modeBits) << bgShift)
+	return LogColor(bits | uint64(mode)<<bgShift | uint64(value)<<(bgShift+modeBits))
+}
+
+// bits returns the bits of the color, the int is not sign-extended on the 32-bit platforms.
+func (c LogColor) bits() uint64 {
+	return uint64(uint(c))
+}
+
+func (c LogColor) fg() (mode, value uint32) {
+	return uint32(c.bits()>>fgShift) & (1<<modeBits - 1), uint32(c.bits()>>(fgShift+modeBits)) & valueMask
+}
+
+func (c LogColor) bg() (mode, value uint32) {
+	return uint32(c.bits()>>bgShift) & (1<<modeBits - 1), uint32(c.bits()>>(bgShift+modeBits)) & valueMask
+}
+
+// appendSGR appends the SGR sequence of the color, nothing if the color is zero.
+func appendSGR(b []byte, c LogColor) []byte {
+	if c == 0 {
+		return b
+	}
+	n := len(b)
+	b = append(b, "\x1b["...)
+	param := func(p int) {
+		if len(b) > n+2 {
+			b = append(b, ';')
+		}
+		b = strconv.AppendInt(b, int64(p), 10)
+	}
+	if code := int(c & basicMask); code != 0 {
+		param(code)
+	}
+	for i, attr := range []LogColor{Bold, Dim, Italic, Underline} {
+		if c&attr != 0 {
+			param(i + 1)
+		}
+	}
+	appendMode := func(base int, mode, value uint32) {
+		switch mode {
+		case modeBasic:
+			param(int(value) + 10)
+		case mode256:
+			param(base)
+			param(5)
+			param(int(value))
+		case modeRGB:
+			param(base)
+			param(2)
+			param(int(value >> 16))
+			param(int(value >> 8 & 0xff))
+			param(int(value & 0xff))
+		}
+	}
+	mode, value := c.fg()
+	appendMode(38, mode, value)
+	mode, value = c.bg()
+	appendMode(48, mode, value)
+	if len(b) == n+2 {
+		return b[:n]
+	}
+	return append(b, 'm')
+}
+
+// String returns the SGR sequence of the color.
+func (c LogColor) String() string {
+	return string(appendSGR(nil, c))
+}
+
+// colorEnabled reports whether the Handler emits the colors.
+func (h *Handler) colorEnabled() bool {
+	return h.colorMode == ColorAlways
+}
+
+// setColor appends the SGR sequence of the color if the colors are enabled, it reports whether it's appended.
+func (s *handleState) setColor(c LogColor) bool {
+	if c == 0 || !s.h.colorEnabled() {
+		return false
+	}
+	*s.buf = appendSGR(*s.buf, c)
+	return true
+}
+
+// unsetColor appends the SGR reset sequence if the color is set.
+func (s *handleState) unsetColor(set bool) {
+	if set {
+		s.buf.WriteString("\x1b[0m")
+	}
+}
+
+// colorNames maps the names of the colors to their codes, the bright colors have the "hi" prefix.
+var colorNames = map[string]LogColor{
+	"black":   LogColor(color.FgBlack),
+	"red":     LogColor(color.FgRed),
+	"green":   LogColor(color.FgGreen),
+	"yellow":  LogColor(color.FgYellow),
+	"blue":    LogColor(color.FgBlue),
+	"magenta": LogColor(color.FgMagenta),
+	"cyan":    LogColor(color.FgCyan),
+	"white":   LogColor(color.FgWhite),
+}
+
+// attrNames maps the names of the attributes to their bits.
+var attrNames = map[string]LogColor{
+	"bold":      Bold,
+	"dim":       Dim,
+	"italic":    Italic,
+	"underline": Underline,
+}
+
+// ParseColor parses the color, it ignores the case. ParseColor can be used to read colors
+// from config files and environment variables. The color is the `+` separated list of:
+//
+//	the names, e.g. "red", "hired" or "hi-red"
+//	the numeric SGR codes, e.g. "31"
+//	the 256-color palette colors, e.g. "256:208"
+//	the truecolor colors, e.g. "#ff8800"
+//	the attributes: bold, dim, italic and underline
+//	the backgrounds with the "bg:" prefix, e.g. "bg:blue" or "bg:#202020"
+//
+// For example, "hired+bold+bg:256:236".
+func ParseColor(name string) (LogColor, error) {
+	var c LogColor
+	for _, part := range strings.Split(strings.ToLower(strings.TrimSpace(name)), "+") {
+		part = strings.TrimSpace(part)
+		if attr, ok := attrNames[part]; ok {
+			c |= attr
+			continue
+		}
+		bg, isBg := strings.CutPrefix(part, "bg:")
+		if isBg {
+			part = bg
+		}
+		fg, ok := parseFgColor(part)
+		if !ok {
+			return 0, fmt.Errorf("otris: unknown color %q", name)
+		}
+		if isBg {
+			c = c.WithBackground(fg)
+		} else {
+			c = LogColor(c.bits()&^(basicMask|(1<<modeBits-1|valueMask<<modeBits)<<fgShift) | fg.bits())
+		}
+	}
+	return c, nil
+}
+
+// parseFgColor parses a single foreground color.
+func parseFgColor(s string) (LogColor, bool) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 107 {
+		return LogColor(n), true
+	}
+	if c, ok := colorNames[s]; ok {
+		return c, true
+	}
+	for _, prefix := range []string{"hi-", "hi", "bright-", "bright"} {
+		if name, ok := strings.CutPrefix(s, prefix); ok {
+			if c, ok := colorNames[name]; ok {
+				return c + 60, true
+			}
+		}
+	}
+	if n, ok := strings.CutPrefix(s, "256:"); ok {
+		if v, err := strconv.ParseUint(n, 10, 8); err == nil {
+			return Color256(uint8(v)), true
+		}
+	}
+	if hex, ok := strings.CutPrefix(s, "#"); ok && len(hex) == 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package otris
+
+import (
+	"bytes"
+	"github.com/fatih/color"
+	"log/slog"
+	"testing"
+)
+
+func TestLogColor(t *testing.T) {
+	// Test cases
+	cases := []struct {
+		name  string
+		color LogColor
+		want  string
+		wide  bool // needs the 64-bit LogColor
+	}{
+		{name: "Zero", color: 0, want: ""},
+		{name: "Basic", color: LogColor(color.FgRed), want: "\x1b[31m"},
+		{name: "Attributes", color: LogColor(color.FgHiRed) | Bold | Underline, want: "\x1b[91;1;4m"},
+		{name: "Color256", color: Color256(208) | Italic, want: "\x1b[3;38;5;208m"},
+		{name: "RGB", color: RGB(255, 136, 0), want: "\x1b[38;2;255;136;0m", wide: true},
+		{name: "BasicBackground", color: LogColor(color.FgWhite).WithBackground(LogColor(color.FgBlue)), want: "\x1b[37;44m", wide: true},
+		{name: "RGBBackground", color: Dim.WithBackground(RGB(32, 32, 32)), want: "\x1b[2;48;2;32;32;32m", wide: true},
+		{name: "BackgroundOnly", color: LogColor(0).WithBackground(Color256(236)), want: "\x1b[48;5;236m", wide: true},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			if test.wide && !wideColors {
+				t.Skip("the truecolor and background colors need the 64-bit int")
+			}
+			if got := test.color.String(); got != test.want {
+				t.Errorf("\ngot  %q\nwant %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	// Test cases
+	cases := []struct {
+		name string
+		want LogColor
+	}{
+		{name: "red", want: LogColor(color.FgRed)},
+		{name: "Hi-Red", want: LogColor(color.FgHiRed)},
+		{name: "brightblue", want: LogColor(color.FgHiBlue)},
+		{name: "41", want: LogColor(color.BgRed)},
+		{name: "256:208+bold", want: Color256(208) | Bold},
+		{name: "#ff8800 + underline", want: RGB(255, 136, 0) | Underline},
+		{name: "white+bg:blue", want: LogColor(color.FgWhite).WithBackground(LogColor(color.FgBlue))},
+		{name: "bg:#202020+dim", want: Dim.WithBackground(RGB(32, 32, 32))},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseColor(test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("\ngot  %q\nwant %q", got, test.want)
+			}
+		})
+	}
+
+	for _, name := range []string{"", "pink", "256:300", "#ff88", "bg:bold", "red+"} {
+		if _, err := ParseColor(name); err == nil {
+			t.Errorf("ParseColor(%q) doesn't return an error", name)
+		}
+	}
+}
+
+func TestHandlerColorMode(t *testing.T) {
+	var colored, plain bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTime}
+	colorMap := LevelColorMap{LevelInfo: Color256(46) | Bold}
+	console := NewHandlerBuilder().WithPretty().WithColor(colorMap).WithWriter(&colored).WithOptions(opts).WithColorMode(ColorAlways).Build()
+	file := NewHandlerBuilder().WithPretty().WithColor(colorMap).WithWriter(&plain).WithOptions(opts).Build()
+
+	slog.New(console).Info("message")
+	slog.New(file).Info("message")
+
+	if want := "\x1b[1;38;5;46mINFO\x1b[0m | message\n"; colored.String() != want {
+		t.Errorf("\ngot  %q\nwant %q", colored.String(), want)
+	}
+	if want := "INFO | message\n"; plain.String() != want {
+		t.Errorf("\ngot  %q\nwant %q", plain.String(), want)
+	}
+}
@@ -9,9 +9,9 @@ package otris
 import (
 	"fmt"
 	"github.com/Totus-Floreo/otris/internal/slog/buffer"
-	"github.com/fatih/color"
 	"log/slog"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,13 +20,18 @@ import (
 // The initial value of sep determines whether to emit a separator
 // before the next key, after which it stays true.
 type handleState struct {
-	h       *Handler
-	buf     *buffer.Buffer
-	color   int
-	freeBuf bool           // should buf be freed?
-	sep     string         // separator to write before next key
-	prefix  *buffer.Buffer // for text: key prefix
-	groups  *[]string      // pool-allocated slice of active groups, for ReplaceAttr
+	h        *Handler
+	buf      *buffer.Buffer
+	color    LogColor
+	freeBuf  bool           // should buf be freed?
+	builtin  bool           // are built-in attributes being appended?
+	padEnd   int            // end of the padding of the last column
+	tail     *buffer.Buffer // for multi-line pretty: lines beneath the main line
+	depth    int            // for multi-line pretty: depth of the deferred groups
+	sep      string         // separator to write before next key
+	prefix   *buffer.Buffer // for text: key prefix
+	groups   *[]string      // pool-allocated slice of active groups, for ReplaceAttr
+	repeated int            // for dedup: repeat count of the summary record
 }
 
 var groupPool = sync.Pool{New: func() any {
@@ -39,7 +44,6 @@ func (h *Handler) newHandleState(buf *buffer.Buffer, freeBuf bool, sep string) h
 		h:       h,
 		buf:     buf,
 		freeBuf: freeBuf,
-		color:   noColor,
 		sep:     sep,
 		prefix:  buffer.New(),
 	}
@@ -50,16 +54,16 @@ func (h *Handler) newHandleState(buf *buffer.Buffer, freeBuf bool, sep string) h
 	return s
 }
 
-// noColor is used to check that an attribute should not have a color.
-const noColor = -1
-
 func (s *handleState) resetColor() {
-	s.color = noColor
+	s.color = 0
 }
 
 func (s *handleState) free() {
 	if s.freeBuf {
 		s.buf.Free()
+		if s.tail != nil {
+			s.tail.Free()
+		}
 	}
 	if gs := s.groups; gs != nil {
 		*gs = (*gs)[:0]
@@ -107,10 +111,9 @@ func (s *handleState) closeGroup(name string) {
 	}
 }
 
-// appendAttr appends the Attr's key and value using app.
-// It handles replacement and checking for an empty key.
+// appendAttr appends the Attr's key and value.
+// It handles replacement and checking for an empty key
 // after replacement.
-// TODO Refactor this in v2
 func (s *handleState) appendAttr(a slog.Attr) {
 	if rep := s.h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
 		var gs []string
@@ -126,6 +129,23 @@ func (s *handleState) appendAttr(a slog.Attr) {
 	if isEmpty(a) {
 		return
 	}
+	// Special case: Level, it keeps the otris name and color.
+	if v := a.Value; v.Kind() == slog.KindAny {
+		if lvl, ok := v.Any().(slog.Level); ok {
+			s.appendLevel(a.Key, lvl)
+			return
+		}
+	}
+	// Special case: the message of the dedup summary, the count keeps the repeat color.
+	if v := a.Value; v.Kind() == slog.KindAny {
+		if n, ok := v.Any().(repeatedMessage); ok {
+			s.appendKey(a.Key)
+			start := len(*s.buf)
+			s.appendRepeated(n)
+			s.alignColumn(a.Key, start)
+			return
+		}
+	}
 	// Special case: Source.
 	if v := a.Value; v.Kind() == slog.KindAny {
 		if src, ok := v.Any().(*slog.Source); ok {
@@ -136,6 +156,10 @@ func (s *handleState) appendAttr(a slog.Attr) {
 			}
 		}
 	}
+	if s.isDeferred(a) {
+		s.appendTreeAttr(a)
+		return
+	}
 	if a.Value.Kind() == slog.KindGroup {
 		attrs := a.Value.Group()
 		// Output only non-empty groups.
@@ -153,17 +177,65 @@ func (s *handleState) appendAttr(a slog.Attr) {
 		}
 	} else {
 		s.appendKey(a.Key)
-		s.appendValue(a.Value)
+		start := len(*s.buf)
+		if c, ok := s.valueColor(a); ok {
+			s.appendColoredValue(a.Value, c)
+		} else {
+			s.appendValue(a.Value)
+		}
+		s.alignColumn(a.Key, start)
 	}
 }
 
+// appendLevel appends the level with its otris name in the color of the level.
+func (s *handleState) appendLevel(key string, lvl slog.Level) {
+	s.appendKey(key)
+	start := len(*s.buf)
+	s.color = GetLogColor(s.h.color, lvl)
+	if s.h.pretty && s.h.shortLevels {
+		s.appendString(GetLevelShortName(lvl))
+	} else {
+		s.appendString(GetLevelName(lvl))
+	}
+	s.resetColor()
+	s.alignColumn(key, start)
+}
+
+// valueColor returns the color of the attribute value from the value color map.
+// The key with the group prefix takes precedence over the bare key.
+func (s *handleState) valueColor(a slog.Attr) (LogColor, bool) {
+	if !s.h.pretty || len(s.h.valueColor) == 0 {
+		return 0, false
+	}
+	if s.prefix != nil && len(*s.prefix) > 0 {
+		if c, ok := s.h.valueColor.Color(string(*s.prefix)+a.Key, a.Value); ok {
+			return c, true
+		}
+	}
+	return s.h.valueColor.Color(a.Key, a.Value)
+}
+
+// appendColoredValue appends the value wrapped in the SGR sequence of the color.
+func (s *handleState) appendColoredValue(v slog.Value, c LogColor) {
+	set := s.setColor(c)
+	s.appendValue(v)
+	s.unsetColor(set)
+}
+
 func (s *handleState) appendError(err error) {
 	s.appendString(fmt.Sprintf("!ERROR:%v", err))
 }
 
 func (s *handleState) appendKey(key string) {
 	s.buf.WriteString(s.sep)
-	if !s.h.pretty {
+	if s.h.logfmt {
+		*s.buf = appendLogfmtKey(*s.buf, *s.prefix)
+		*s.buf = appendLogfmtKey(*s.buf, []byte(key))
+		if len(*s.prefix) == 0 && key == "" {
+			s.buf.WriteByte('_')
+		}
+		s.buf.WriteByte('=')
+	} else if !s.h.pretty {
 		if s.prefix != nil && len(*s.prefix) > 0 {
 			// This TODO from slog lib, msg: optimize by avoiding allocation.
 			s.appendString(string(*s.prefix) + key)
@@ -175,22 +247,47 @@ func (s *handleState) appendKey(key string) {
 		} else {
 			s.buf.WriteByte('=')
 		}
+	} else if s.h.prettyKeys && !s.builtin && !s.h.isValueOnly(s.prefix.String(), key) {
+		s.appendPrettyKey(key)
 	}
 	s.sep = s.h.attrSep()
 }
 
+// appendPrettyKey appends the key with its group prefix in the key color.
+// Unlike the text mode, the key is never quoted.
+func (s *handleState) appendPrettyKey(key string) {
+	set := s.setColor(s.h.keyColor)
+	s.buf.Write(*s.prefix)
+	s.buf.WriteString(key)
+	s.buf.WriteByte('=')
+	s.unsetColor(set)
+}
+
 func (s *handleState) appendString(str string) {
 	if s.h.json {
 		s.buf.WriteByte('"')
 		*s.buf = appendEscapedJSONString(*s.buf, str)
 		s.buf.WriteByte('"')
+	} else if s.h.logfmt {
+		if needsLogfmtQuoting(str) {
+			s.buf.WriteByte('"')
+			*s.buf = appendEscapedJSONString(*s.buf, str)
+			s.buf.WriteByte('"')
+		} else {
+			s.buf.WriteString(str)
+		}
 	} else {
 		// slog.text and otris.pretty
 		if needsQuoting(str) && s.h.safe {
 			*s.buf = strconv.AppendQuote(*s.buf, str)
 		} else {
-			if s.color > noColor {
-				color.New(color.Attribute(s.color)).Fprint(s.buf, str)
+			if s.needsPrettyQuoting(str) {
+				str = strconv.Quote(str)
+			}
+			if s.color != 0 {
+				set := s.setColor(s.color)
+				s.buf.WriteString(str)
+				s.unsetColor(set)
 				return
 			}
 			s.buf.WriteString(str)
@@ -198,6 +295,15 @@ func (s *handleState) appendString(str string) {
 	}
 }
 
+// needsPrettyQuoting reports whether the string is quoted in pretty mode, so the Parser splits the line correctly:
+// the string contains the separator or starts with a quote.
+func (s *handleState) needsPrettyQuoting(str string) bool {
+	if !s.h.pretty {
+		return false
+	}
+	return strings.HasPrefix(str, `"`) || strings.TrimSpace(s.h.sep) != "" && strings.Contains(str, s.h.sep)
+}
+
 func (s *handleState) appendValue(v slog.Value) {
 	var err error
 	if s.h.json {
@@ -262,21 +368,24 @@ func writeTimeRFC3339Millis(buf *buffer.Buffer, t time.Time) {
 	}
 }
 
-func (s *handleState) appendNonBuiltIns(r slog.Record) {
+func (s *handleState) appendNonBuiltIns(r slog.Record, ctxAttrs []slog.Attr) {
 	// preformatted Attrs
 	if len(s.h.preformattedAttrs) > 0 {
 		s.buf.WriteString(s.sep)
 		s.buf.Write(s.h.preformattedAttrs)
 		s.sep = s.h.attrSep()
 	}
-	// Attrs in Record -- unlike the built-in ones, they are in groups started
+	// Attrs in Record and in the context -- unlike the built-in ones, they are in groups started
 	// from WithGroup.
 	// If the record has no Attrs, don't output any groups.
 	nOpenGroups := s.h.nOpenGroups
-	if r.NumAttrs() > 0 {
+	if r.NumAttrs() > 0 || len(ctxAttrs) > 0 {
 		s.prefix.WriteString(s.h.groupPrefix)
 		s.openGroups()
 		nOpenGroups = len(s.h.groups)
+		for _, a := range ctxAttrs {
+			s.appendAttr(a)
+		}
 		r.Attrs(func(a slog.Attr) bool {
 			s.appendAttr(a)
 			return true
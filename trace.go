@@ -0,0 +1,140 @@
+package otris
+
+import (
+	"context"
+	"errors"
+	"github.com/fatih/color"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+)
+
+// TraceIDKey and SpanIDKey are the keys of the trace correlation attributes.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceProvider reads the W3C trace and span IDs of the current span from the context,
+// as lowercase hex strings of 32 and 16 characters. It reports false if the context has no valid span.
+// It lets the handler correlate logs with any tracing library without depending on it, e.g. for OpenTelemetry:
+//
+//	func(ctx context.Context) (string, string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	}
+type TraceProvider func(ctx context.Context) (traceID, spanID string, ok bool)
+
+// Traceparent holds the IDs of a W3C `traceparent` header.
+type Traceparent struct {
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for the malformed headers.
+var ErrInvalidTraceparent = errors.New("otris: invalid traceparent")
+
+// ParseTraceparent parses the W3C `traceparent` header, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(header string) (Traceparent, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return Traceparent{}, ErrInvalidTraceparent
+	}
+	tp := Traceparent{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+	if !isHexID(parts[0], 2) || !isHexID(tp.TraceID, 32) || !isHexID(tp.SpanID, 16) || !isHexID(tp.Flags, 2) ||
+		isZeroID(tp.TraceID) || isZeroID(tp.SpanID) {
+		return Traceparent{}, ErrInvalidTraceparent
+	}
+	return tp, nil
+}
+
+// String returns the W3C `traceparent` header of the version 00.
+func (tp Traceparent) String() string {
+	return "00-" + tp.TraceID + "-" + tp.SpanID + "-" + tp.Flags
+}
+
+type traceparentKey struct{}
+
+// ContextWithTraceparent returns a copy of ctx carrying the IDs of the W3C `traceparent` header,
+// they are read by TraceparentProvider.
+func ContextWithTraceparent(ctx context.Context, header string) (context.Context, error) {
+	tp, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, traceparentKey{}, tp), nil
+}
+
+// TraceparentProvider is the TraceProvider for the contexts from ContextWithTraceparent.
+func TraceparentProvider(ctx context.Context) (traceID, spanID string, ok bool) {
+	tp, ok := ctx.Value(traceparentKey{}).(Traceparent)
+	return tp.TraceID, tp.SpanID, ok
+}
+
+func isHexID(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroID(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// traceColors is the palette of the trace tags, the color is picked by the trace ID,
+// so the lines of a trace have the same color.
+var traceColors = []LogColor{
+	LogColor(color.FgCyan),
+	LogColor(color.FgMagenta),
+	LogColor(color.FgBlue),
+	LogColor(color.FgGreen),
+	LogColor(color.FgHiCyan),
+	LogColor(color.FgHiMagenta),
+	LogColor(color.FgHiBlue),
+	LogColor(color.FgHiYellow),
+}
+
+// traceTagLen is the number of characters of the trace and span IDs in the trace tag.
+const traceTagLen = 8
+
+// appendTrace appends the trace and span IDs from the context.
+// In pretty mode, they are appended as a short colored tag, e.g. "4bf92f35:00f067aa".
+func (s *handleState) appendTrace(ctx context.Context) {
+	if s.h.trace == nil || ctx == nil {
+		return
+	}
+	traceID, spanID, ok := s.h.trace(ctx)
+	if !ok || traceID == "" {
+		return
+	}
+	if !s.h.pretty {
+		s.appendAttr(slog.String(TraceIDKey, traceID))
+		if spanID != "" {
+			s.appendAttr(slog.String(SpanIDKey, spanID))
+		}
+		return
+	}
+	tag := shortID(traceID)
+	if spanID != "" {
+		tag += ":" + shortID(spanID)
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(traceID))
+	s.buf.WriteString(s.sep)
+	s.appendColoredValue(slog.StringValue(tag), traceColors[hash.Sum32()%uint32(len(traceColors))])
+	s.sep = s.h.attrSep()
+}
+
+func shortID(id string) string {
+	if len(id) > traceTagLen {
+		return id[:traceTagLen]
+	}
+	return id
+}
@@ -101,7 +101,11 @@ func appendTextValue(s *handleState, v slog.Value) error {
 		}
 		if bs, ok := byteSlice(v.Any()); ok {
 			// As of Go 1.19, this only allocates for strings longer than 32 bytes.
-			if !s.h.safe && s.h.pretty {
+			if s.h.logfmt {
+				s.appendString(string(bs))
+				return nil
+			}
+			if !s.h.safe && s.h.pretty && !s.needsPrettyQuoting(string(bs)) {
 				s.buf.WriteString(string(bs))
 				return nil
 			}
@@ -181,6 +185,56 @@ func needsQuoting(s string) bool {
 	return false
 }
 
+// Logfmt Handler
+
+// needsLogfmtQuoting reports whether the logfmt value must be quoted.
+// Bare values can't be empty and can't contain spaces, '=', '"', '\\' or control characters.
+func needsLogfmtQuoting(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// appendLogfmtKey appends the key to dst, replacing the characters
+// that are not allowed in logfmt keys with '_'.
+func appendLogfmtKey(dst []byte, key []byte) []byte {
+	for i := 0; i < len(key); {
+		b := key[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+				b = '_'
+			}
+			dst = append(dst, b)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(key[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			dst = append(dst, '_')
+		} else {
+			dst = append(dst, key[i:i+size]...)
+		}
+		i += size
+	}
+	return dst
+}
+
 // JSON Handler
 
 // Adapted from time.Time.MarshalJSON to avoid allocation.